@@ -6,6 +6,8 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/siakhooi/picsum/internal/cli"
 	"github.com/siakhooi/picsum/internal/console"
@@ -19,5 +21,15 @@ func main() {
 }
 
 func run(args []string) error {
-	return cli.BuildCommand().Run(context.Background(), args)
+	// Cancel in-flight requests on Ctrl-C or termination so partial downloads are cleaned up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Restore the default handling after the first signal, so a second one
+	// ends the process even when it is stuck outside of ctx
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return cli.BuildCommand().Run(ctx, args)
 }
@@ -2,4 +2,8 @@ module github.com/siakhooi/picsum
 
 go 1.26.2
 
-require github.com/urfave/cli/v3 v3.10.1
+require (
+	github.com/urfave/cli/v3 v3.10.1
+	golang.org/x/image v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
+)
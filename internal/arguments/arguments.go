@@ -4,23 +4,95 @@ Package arguments to process arguments
 package arguments
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/siakhooi/picsum/internal/batch"
+	"github.com/siakhooi/picsum/internal/cache"
+	"github.com/siakhooi/picsum/internal/checksum"
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/convert"
 	"github.com/siakhooi/picsum/internal/download"
+	"github.com/siakhooi/picsum/internal/filter"
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/nametemplate"
 	"github.com/siakhooi/picsum/internal/output"
+	"github.com/siakhooi/picsum/internal/provider"
+	"github.com/siakhooi/picsum/internal/sidecar"
+	"github.com/siakhooi/picsum/internal/transform"
 	"github.com/siakhooi/picsum/internal/urlbuilder"
 )
 
 // Options holds all command-line flag values
 type Options struct {
-	ImageID    string
-	Seed       string
-	Grayscale  bool
-	Blur       bool
-	BlurLevel  int
-	Quiet      bool
-	OutputPath string
-	Force      bool
+	ImageID        string
+	Seed           string
+	Grayscale      bool
+	Blur           bool
+	BlurLevel      int
+	Quiet          bool
+	OutputPath     string
+	Force          bool
+	Count          int
+	Concurrency    int
+	Metadata       bool
+	PrintID        bool
+	NameTemplate   string
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+	Retries        int
+	RetryMaxWait   time.Duration
+	CacheDir       string
+	Offline        bool
+	NoCache        bool
+	BaseURL        string
+	Provider       string
+	ProviderConfig string
+	ChecksumFile   string
+	Format         string
+	Convert        string
+	Quality        int
+	NoValidate     bool
+	Resize         string
+	Crop           string
+	Fit            string
+	PadColor       string
+	Filter         string
+	IDPrefix       string
+}
+
+// SavedImage describes an image written to disk
+type SavedImage struct {
+	Filename string
+	ImageID  string
+	// FinalURL is the URL the image was served from after redirects
+	FinalURL string
+	// SHA256 is the hex digest of the saved bytes
+	SHA256 string
+}
+
+// target describes how the filename of a downloaded image is derived
+type target struct {
+	// name is the output filename, a template when expand is set
+	name string
+	// expand replaces placeholders once the served image is known
+	expand bool
+	// mkdir creates missing directories of the expanded name
+	mkdir  bool
+	values nametemplate.Values
+	// convert is the format the download is re-encoded in, empty to save it as served
+	convert string
+	// transform crops and resizes the download before it is saved, nil to keep it as served
+	transform *transform.Spec
+	// filters are the color effects applied after transform
+	filters filter.Chain
 }
 
 // ValidateArguments validates the number of command-line arguments
@@ -43,33 +115,490 @@ func ValidateOptions(opts *Options) error {
 		opts.Blur = false
 	}
 
+	baseURL, err := urlbuilder.ParseBaseURL(opts.BaseURL)
+	if err != nil {
+		return err
+	}
+	opts.BaseURL = baseURL
+
 	// Check mutual exclusivity
 	if opts.ImageID != "" && opts.Seed != "" {
 		return fmt.Errorf("options --id and --seed are mutually exclusive")
 	}
+
+	// Validate batch options, 0 selects the default of a single image and of batch.DefaultConcurrency workers
+	if opts.Count < 0 {
+		return fmt.Errorf("count must not be negative, got %d", opts.Count)
+	}
+	if opts.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative, got %d", opts.Concurrency)
+	}
+	if opts.Count > 1 && (opts.ImageID != "" || opts.Seed != "") {
+		return fmt.Errorf("option --count cannot be combined with --id or --seed")
+	}
+
+	if opts.Timeout < 0 || opts.ConnectTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if opts.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got %d", opts.Retries)
+	}
+	if opts.RetryMaxWait < 0 {
+		return fmt.Errorf("retry max wait must not be negative")
+	}
+	if opts.Offline && opts.NoCache {
+		return fmt.Errorf("options --offline and --no-cache are mutually exclusive")
+	}
+	// Streaming to stdout leaves no room for messages or a second file
+	if opts.OutputPath == output.StdoutPath {
+		if opts.Count > 1 {
+			return fmt.Errorf("option --count cannot be used with --output -")
+		}
+		if opts.Metadata {
+			return fmt.Errorf("option --metadata cannot be used with --output -")
+		}
+		if opts.ChecksumFile != "" {
+			return fmt.Errorf("option --checksum-file cannot be used with --output -")
+		}
+		opts.Quiet = true
+	}
+
+	if err := urlbuilder.ValidateFormat(opts.Format); err != nil {
+		return err
+	}
+	if err := validateConversion(opts); err != nil {
+		return err
+	}
+
+	return nametemplate.Validate(opts.NameTemplate)
+}
+
+// validateConversion checks --convert, --quality, the transforms and filters against the output path and the requested format
+func validateConversion(opts *Options) error {
+	target := conversion(opts)
+	if err := convert.Validate(target, opts.Quality); err != nil {
+		return err
+	}
+	if named := convert.FromFilename(opts.OutputPath); opts.Convert != "" && named != "" && named != opts.Convert {
+		return fmt.Errorf("option --convert %s does not match the extension of --output %s", opts.Convert, opts.OutputPath)
+	}
+	spec, err := transform.Parse(opts.Resize, opts.Crop, opts.Fit, opts.PadColor)
+	if err != nil {
+		return err
+	}
+	filters, err := filter.Parse(opts.Filter)
+	if err != nil {
+		return err
+	}
+	// WebP is decoded but cannot be encoded again, a transformed or filtered WebP download needs another format
+	if (spec != nil || filters != nil) && target == "" && opts.Format == urlbuilder.FormatWebP {
+		return fmt.Errorf("option --format %s cannot be re-encoded after a transform or filter, set --convert to %s, %s or %s",
+			urlbuilder.FormatWebP, convert.PNG, convert.GIF, convert.JPEG)
+	}
 	return nil
 }
 
+/*
+conversion returns the format the image is converted to: --convert, else
+the format named by the --output extension, else JPEG when only --quality is set
+*/
+func conversion(opts *Options) string {
+	if opts.Convert != "" {
+		return opts.Convert
+	}
+	if format := convert.FromFilename(opts.OutputPath); format != "" {
+		return format
+	}
+	if opts.Quality > 0 {
+		return convert.JPEG
+	}
+	return ""
+}
+
+/*
+NewHTTPClient creates the HTTP client configured by the timeout, retry and
+cache options. Deterministic responses are kept in opts.CacheDir, or in the
+user cache directory when it is empty, unless opts.NoCache is set.
+A provider that generates its images, such as local, supplies its own client instead.
+*/
+func NewHTTPClient(opts *Options) (httpclient.Getter, error) {
+	p, err := NewProvider(opts)
+	if err != nil {
+		return nil, err
+	}
+	if client := p.Client(); client != nil {
+		return client, nil
+	}
+
+	client := httpclient.NewClient(opts.Timeout, opts.ConnectTimeout)
+	client = httpclient.NewRetryingClient(client, opts.Retries, opts.RetryMaxWait)
+	if opts.NoCache {
+		return client, nil
+	}
+
+	store, err := cache.Open(opts.CacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("%v, set --cache-dir or --no-cache", err)
+	}
+	baseURL, err := urlbuilder.ParseBaseURL(opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewClient(client, store, baseURL, opts.Offline), nil
+}
+
+// NewProvider returns the provider selected by opts, serving the URLs under opts.BaseURL
+func NewProvider(opts *Options) (provider.Provider, error) {
+	baseURL, err := urlbuilder.ParseBaseURL(opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Lookup(opts.Provider, baseURL, opts.ProviderConfig)
+}
+
 // ProcessImage handles the complete image processing workflow
-func ProcessImage(args []string, opts *Options) error {
-	// Build URL and filename based on arguments
-	url, filename, err := urlbuilder.BuildURL(args, opts.ImageID, opts.Seed, opts.Grayscale, opts.Blur, opts.BlurLevel)
+// Uses an HTTP client configured by opts
+func ProcessImage(ctx context.Context, args []string, opts *Options) error {
+	client, err := NewHTTPClient(opts)
+	if err != nil {
+		return err
+	}
+	return ProcessImageWithClient(ctx, client, args, opts)
+}
+
+// ProcessImageWithClient handles the complete image processing workflow using the provided HTTP client
+func ProcessImageWithClient(ctx context.Context, client httpclient.Getter, args []string, opts *Options) error {
+	p, url, out, err := prepare(args, opts)
 	if err != nil {
 		return err
 	}
 
-	// Use custom output path if specified
+	if opts.Count > 1 {
+		return processBatch(ctx, client, p, url, out, opts)
+	}
+
+	saved, err := fetchAndSave(ctx, client, p, url, out, opts.Quiet, opts)
+	if err != nil {
+		return err
+	}
+	if opts.PrintID {
+		printID(saved, opts.OutputPath == output.StdoutPath, opts.IDPrefix)
+	}
+	return nil
+}
+
+/*
+SaveImageWithClient downloads the single image selected by args and opts,
+ignoring opts.Count, and describes the saved file
+*/
+func SaveImageWithClient(ctx context.Context, client httpclient.Getter, args []string, opts *Options) (*SavedImage, error) {
+	p, url, out, err := prepare(args, opts)
+	if err != nil {
+		return nil, err
+	}
+	return fetchAndSave(ctx, client, p, url, out, opts.Quiet, opts)
+}
+
+// prepare selects the provider and builds the URL and target of the requested image
+func prepare(args []string, opts *Options) (provider.Provider, string, target, error) {
+	p, err := NewProvider(opts)
+	if err != nil {
+		return nil, "", target{}, err
+	}
+
+	// Build URL and filename based on arguments
+	req := provider.Request{
+		Args:      args,
+		ImageID:   opts.ImageID,
+		Seed:      opts.Seed,
+		Grayscale: opts.Grayscale,
+		Blur:      opts.Blur,
+		BlurLevel: opts.BlurLevel,
+		Format:    opts.Format,
+	}
+	url, err := p.BuildURL(req)
+	if err != nil {
+		return nil, "", target{}, err
+	}
+	filename, err := p.BuildFilename(req)
+	if err != nil {
+		return nil, "", target{}, err
+	}
+
+	out, err := buildTarget(args, filename, opts)
+	if err != nil {
+		return nil, "", target{}, err
+	}
+	return p, url, out, nil
+}
+
+// buildTarget selects the custom output path, the name template or the default filename
+func buildTarget(args []string, filename string, opts *Options) (target, error) {
+	width, height, err := urlbuilder.ParseSize(args)
+	if err != nil {
+		return target{}, err
+	}
+
+	out := target{
+		name: filename,
+		values: nametemplate.Values{
+			ID:        opts.ImageID,
+			Seed:      opts.Seed,
+			Width:     width,
+			Height:    height,
+			Grayscale: opts.Grayscale,
+			Blur:      opts.Blur,
+			BlurLevel: opts.BlurLevel,
+			Date:      time.Now(),
+			N:         1,
+			Ext:       strings.TrimPrefix(filepath.Ext(filename), "."),
+		},
+	}
+
+	// Re-encode unless the download already is in the target format at the default quality
+	if format := conversion(opts); format != "" && (format != convert.FromFilename(filename) || opts.Quality > 0) {
+		out.convert = format
+		out.values.Ext = convert.Extension(format)
+		out.name = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + out.values.Ext
+	}
+	if out.transform, err = transform.Parse(opts.Resize, opts.Crop, opts.Fit, opts.PadColor); err != nil {
+		return target{}, err
+	}
+	if out.filters, err = filter.Parse(opts.Filter); err != nil {
+		return target{}, err
+	}
+
+	// Use custom output path if specified, otherwise the name template
 	if opts.OutputPath != "" {
-		filename = opts.OutputPath
+		out.name = opts.OutputPath
+		out.expand = true
+	} else if opts.NameTemplate != "" {
+		out.name = opts.NameTemplate
+		out.expand = true
+		out.mkdir = true
+	}
+	return out, nil
+}
+
+// numbered returns the target of the n-th image of a batch of total images
+func (t target) numbered(n, total int) target {
+	t.values.N = n
+	if !t.expand || !nametemplate.Uses(t.name, nametemplate.N) {
+		t.name = urlbuilder.NumberedFilename(t.name, n, total)
+	}
+	return t
+}
+
+/*
+knownName returns the filename of the target when it is known before the
+download, false when it depends on the image the server picks through
+{author}, or through {id} when no ID was requested
+*/
+func (t target) knownName() (string, bool) {
+	if !t.expand {
+		return t.name, true
+	}
+	if nametemplate.Uses(t.name, nametemplate.Author) || (nametemplate.Uses(t.name, nametemplate.ID) && t.values.ID == "") {
+		return "", false
+	}
+	return nametemplate.Expand(t.name, t.values), true
+}
+
+/*
+TargetFilenames returns the files the images requested by args and opts are
+saved as, after the name template is expanded. Names that are only known
+once the server has picked the image are left out.
+*/
+func TargetFilenames(args []string, opts *Options) ([]string, error) {
+	_, _, out, err := prepare(args, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Count <= 1 {
+		if name, ok := out.knownName(); ok {
+			return []string{name}, nil
+		}
+		return nil, nil
+	}
+
+	var names []string
+	for n := 1; n <= opts.Count; n++ {
+		if name, ok := out.numbered(n, opts.Count).knownName(); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// printID prints the resolved image ID so that the image can be fetched again with --id,
+// on standard error when standard output carries the image itself. prefix tells apart
+// the IDs of --input jobs
+func printID(saved *SavedImage, streaming bool, prefix string) {
+	if saved.ImageID == "" {
+		console.Stderrln("%sImage ID of %s was not reported by the server", prefix, saved.Filename)
+		return
+	}
+	if streaming {
+		console.Stderrln("%s%s", prefix, saved.ImageID)
+		return
+	}
+	console.Stdoutln("%s%s", prefix, saved.ImageID)
+}
+
+// processBatch downloads opts.Count distinct random images using a worker pool
+func processBatch(ctx context.Context, client httpclient.Getter, p provider.Provider, url string, out target, opts *Options) error {
+	total := opts.Count
+	errs := batch.Run(total, opts.Concurrency, func(i int) error {
+		n := i + 1
+		numbered := out.numbered(n, total)
+
+		saved, err := fetchAndSave(ctx, client, p, p.NumberedURL(url, n), numbered, true, opts)
+		switch {
+		case err != nil:
+			console.Stderrln("[%d/%d] failed: %v", n, total, err)
+		case opts.PrintID:
+			console.Stdoutln("%s[%d/%d] saved %s (id %s)", opts.IDPrefix, n, total, saved.Filename, saved.ImageID)
+		case !opts.Quiet:
+			console.Stdoutln("[%d/%d] saved %s", n, total, saved.Filename)
+		}
+		return err
+	})
+
+	failed := batch.CountFailed(errs)
+	if !opts.Quiet {
+		console.Stdoutln("Downloaded %d of %d images", total-failed, total)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d downloads failed", failed, total)
+	}
+	return nil
+}
+
+// fetchAndSave downloads a single image and saves it to the target filename
+func fetchAndSave(ctx context.Context, client httpclient.Getter, p provider.Provider, url string, out target, quiet bool, opts *Options) (*SavedImage, error) {
+	result, err := download.ImageWithClient(ctx, client, url, quiet)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	if opts.Format != "" {
+		if err := download.CheckContentType(result.Response, urlbuilder.ContentType(opts.Format)); err != nil {
+			return nil, err
+		}
+	}
+
+	if !opts.NoValidate {
+		if err := download.CheckImage(result.Response, out.values.Width, out.values.Height); err != nil {
+			return nil, fmt.Errorf("%v, use --no-validate to save it anyway", err)
+		}
+	}
+
+	saved := &SavedImage{Filename: out.name, ImageID: result.ImageID}
+	if out.expand {
+		if saved.Filename, err = expandTarget(ctx, client, p, out, result.ImageID); err != nil {
+			return nil, err
+		}
+	}
+
+	if out.convert != "" || out.transform != nil || out.filters != nil {
+		if err := processBody(result.Response, out, opts.Quality); err != nil {
+			return nil, err
+		}
+	}
+
+	recorder := sidecar.NewRecorder(url, result.Response)
+	if err := output.SaveImage(ctx, result.Response, saved.Filename, quiet, opts.Force); err != nil {
+		return nil, err
+	}
+	metadata := recorder.Metadata()
+	saved.FinalURL = metadata.FinalURL
+	saved.SHA256 = metadata.SHA256
+
+	if opts.Metadata {
+		if err := writeSidecar(ctx, client, p, metadata, saved, quiet); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ChecksumFile != "" {
+		if err := checksum.Append(opts.ChecksumFile, checksum.Entry{SHA256: saved.SHA256, Path: saved.Filename}); err != nil {
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+/*
+processBody replaces the body of resp with the image transformed, filtered
+and re-encoded in the format of the target, or in its own format if none is set
+*/
+func processBody(resp *http.Response, out target, quality int) error {
+	img, format, err := convert.Decode(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if out.transform != nil {
+		if img, err = transform.Apply(img, out.transform); err != nil {
+			return err
+		}
+	}
+	if out.filters != nil {
+		img = out.filters.Apply(img)
+	}
+	if out.convert != "" {
+		format = out.convert
 	}
 
-	// Download the image
-	resp, err := download.Image(url, opts.Quiet)
+	data, err := convert.Encode(img, format, quality)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	return nil
+}
+
+// expandTarget fills in the placeholders of the target name now that the served image is known
+func expandTarget(ctx context.Context, client httpclient.Getter, p provider.Provider, out target, imageID string) (string, error) {
+	values := out.values
+	if imageID != "" {
+		values.ID = imageID
+	}
+	if nametemplate.Uses(out.name, nametemplate.Author) && values.ID != "" {
+		// The author is a best-effort lookup, {author} falls back to "unknown"
+		if info, err := p.Info(ctx, client, values.ID, ""); err == nil {
+			values.Author = info.Author
+		}
+	}
+
+	filename := nametemplate.Expand(out.name, values)
+	if out.mkdir {
+		if dir := filepath.Dir(filename); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return "", fmt.Errorf("failed to create directory: %v", err)
+			}
+		}
+	}
+	return filename, nil
+}
 
-	// Save the image to file
-	return output.SaveImage(resp, filename, opts.Quiet, opts.Force)
+// writeSidecar writes the provenance record of the saved image, looking up the author when the image ID is known
+func writeSidecar(ctx context.Context, client httpclient.Getter, p provider.Provider, metadata *sidecar.Metadata, saved *SavedImage, quiet bool) error {
+	metadata.PicsumID = saved.ImageID
+	if metadata.PicsumID != "" {
+		// The author is a best-effort lookup, the download itself already succeeded
+		if info, err := p.Info(ctx, client, metadata.PicsumID, ""); err == nil {
+			metadata.Author = info.Author
+			metadata.SourceURL = info.URL
+		}
+	}
+
+	if err := sidecar.Write(saved.Filename, metadata); err != nil {
+		return err
+	}
+	if !quiet {
+		console.Stdoutln("Metadata saved as %s", sidecar.Path(saved.Filename))
+	}
+	return nil
 }
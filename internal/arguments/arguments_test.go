@@ -1,11 +1,50 @@
 package arguments
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/siakhooi/picsum/internal/checksum"
+	"github.com/siakhooi/picsum/internal/provider"
+	"github.com/siakhooi/picsum/internal/synth"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
 )
 
+// mockClient is a mock httpclient.Getter recording requested URLs
+type mockClient struct {
+	mu      sync.Mutex
+	urls    []string
+	ctx     context.Context
+	GetFunc func(url string) (*http.Response, error)
+}
+
+func (m *mockClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	m.mu.Lock()
+	m.urls = append(m.urls, url)
+	m.ctx = ctx
+	m.mu.Unlock()
+	return m.GetFunc(url)
+}
+
+func okResponse(body string) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
 func TestValidateArguments(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -127,6 +166,275 @@ func TestValidateOptions(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid count and concurrency",
+			opts: &Options{
+				Count:       10,
+				Concurrency: 3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative count",
+			opts: &Options{
+				Count: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero count and concurrency select the defaults",
+			opts: &Options{
+				Count:       0,
+				Concurrency: 0,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative concurrency",
+			opts: &Options{
+				Concurrency: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "count with image id",
+			opts: &Options{
+				Count:   2,
+				ImageID: "123",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid name template",
+			opts: &Options{
+				NameTemplate: "{date}/{id}_{width}x{height}.{ext}",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown placeholder in name template",
+			opts: &Options{
+				NameTemplate: "{size}.jpg",
+			},
+			wantErr: true,
+		},
+		{
+			name: "stdout output forces quiet",
+			opts: &Options{
+				OutputPath: "-",
+			},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Quiet
+			},
+		},
+		{
+			name: "stdout output with count",
+			opts: &Options{
+				OutputPath: "-",
+				Count:      2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "stdout output with metadata",
+			opts: &Options{
+				OutputPath: "-",
+				Metadata:   true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "supported format",
+			opts: &Options{
+				Format: "webp",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported format",
+			opts: &Options{
+				Format: "png",
+			},
+			wantErr: true,
+		},
+		{
+			name: "conversion inferred from the output extension",
+			opts: &Options{
+				OutputPath: "hero.png",
+			},
+			wantErr: false,
+		},
+		{
+			name: "jpeg quality",
+			opts: &Options{
+				Convert: "jpeg",
+				Quality: 60,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported conversion",
+			opts: &Options{
+				Convert: "bmp",
+			},
+			wantErr: true,
+		},
+		{
+			name: "quality out of range",
+			opts: &Options{
+				Quality: 101,
+			},
+			wantErr: true,
+		},
+		{
+			name: "quality with png output",
+			opts: &Options{
+				OutputPath: "hero.png",
+				Quality:    80,
+			},
+			wantErr: true,
+		},
+		{
+			name: "conversion contradicting the output extension",
+			opts: &Options{
+				Convert:    "png",
+				OutputPath: "hero.jpg",
+			},
+			wantErr: true,
+		},
+		{
+			name: "conversion of webp",
+			opts: &Options{
+				Format:  "webp",
+				Convert: "png",
+			},
+			wantErr: false,
+		},
+		{
+			name: "resize and fit",
+			opts: &Options{
+				Resize: "8000x6000",
+				Fit:    "cover",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid crop",
+			opts: &Options{
+				Crop: "100x100",
+			},
+			wantErr: true,
+		},
+		{
+			name: "transform of webp",
+			opts: &Options{
+				Format: "webp",
+				Resize: "100x100",
+			},
+			wantErr: true,
+		},
+		{
+			name: "transform of webp saved as png",
+			opts: &Options{
+				Format:     "webp",
+				Resize:     "100x100",
+				OutputPath: "hero.png",
+			},
+			wantErr: false,
+		},
+		{
+			name: "filter of webp",
+			opts: &Options{
+				Format: "webp",
+				Filter: "sepia",
+			},
+			wantErr: true,
+		},
+		{
+			name: "filters",
+			opts: &Options{
+				Filter: "sepia,contrast=0.8",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown filter",
+			opts: &Options{
+				Filter: "vignette",
+			},
+			wantErr: true,
+		},
+		{
+			name: "stdout output with checksum file",
+			opts: &Options{
+				OutputPath:   "-",
+				ChecksumFile: "SHA256SUMS",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative timeout",
+			opts: &Options{
+				Timeout: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "offline with no-cache",
+			opts: &Options{
+				Offline: true,
+				NoCache: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative retries",
+			opts: &Options{
+				Retries: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative retry max wait",
+			opts: &Options{
+				RetryMaxWait: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "count with seed",
+			opts: &Options{
+				Count: 2,
+				Seed:  "myseed",
+			},
+			wantErr: true,
+		},
+		{
+			name: "base URL is normalised",
+			opts: &Options{
+				BaseURL: "http://localhost:8080/picsum/",
+			},
+			wantErr: false,
+			check: func(opts *Options) bool {
+				return opts.BaseURL == "http://localhost:8080/picsum"
+			},
+		},
+		{
+			name:    "empty base URL selects picsum.photos",
+			opts:    &Options{},
+			wantErr: false,
+			check: func(opts *Options) bool {
+				return opts.BaseURL == urlbuilder.DefaultBaseURL
+			},
+		},
+		{
+			name: "invalid base URL",
+			opts: &Options{
+				BaseURL: "ftp://picsum.example.com",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -157,7 +465,7 @@ func TestProcessImage_Success(t *testing.T) {
 	// WHEN
 	// Note: This test will attempt to download from the real picsum.photos
 	// For true unit testing, we would need to refactor ProcessImage to accept dependencies
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	// This is more of an integration test since it calls real implementations
@@ -182,7 +490,7 @@ func TestProcessImage_InvalidArguments(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err == nil {
@@ -206,7 +514,7 @@ func TestProcessImage_WithCustomOutputPath(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -234,7 +542,7 @@ func TestProcessImage_WithGrayscale(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -257,7 +565,7 @@ func TestProcessImage_WithBlur(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -280,7 +588,7 @@ func TestProcessImage_WithBlurLevel(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -303,7 +611,7 @@ func TestProcessImage_WithImageID(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -326,7 +634,7 @@ func TestProcessImage_WithSeed(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -348,7 +656,7 @@ func TestProcessImage_TwoArguments(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -369,7 +677,7 @@ func TestProcessImage_MockDownloadError(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err == nil {
@@ -390,7 +698,7 @@ func TestProcessImage_QuietMode(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -416,7 +724,7 @@ func TestProcessImage_FileExists_Force(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -459,7 +767,7 @@ func TestProcessImage_FileExists_NoForce(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	// User declined, so we expect an error
@@ -487,7 +795,7 @@ func TestProcessImage_CombinedOptions(t *testing.T) {
 	}
 
 	// WHEN
-	err := ProcessImage(args, opts)
+	err := ProcessImage(context.Background(), args, opts)
 
 	// THEN
 	if err != nil {
@@ -495,3 +803,781 @@ func TestProcessImage_CombinedOptions(t *testing.T) {
 		t.Skip("Skipping integration test that requires network access")
 	}
 }
+
+func TestProcessImageWithClient_Single(t *testing.T) {
+	// GIVEN
+	tmpfile := filepath.Join(t.TempDir(), "single.jpg")
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) { return okResponse(url) }}
+	opts := &Options{OutputPath: tmpfile, Quiet: true, Force: true, NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200", "300"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	data, err := os.ReadFile(tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if string(data) != "https://picsum.photos/200/300" {
+		t.Errorf("Unexpected file content %q", string(data))
+	}
+}
+
+func TestSaveImageWithClient_DescribesSavedImage(t *testing.T) {
+	// GIVEN
+	tmpfile := filepath.Join(t.TempDir(), "pinned.jpg")
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		resp, _ := okResponse("image bytes")
+		resp.Header = http.Header{"Picsum-Id": []string{"1025"}}
+		resp.Request, _ = http.NewRequest(http.MethodGet, "https://fastly.picsum.photos/id/1025/200/200.jpg", nil)
+		return resp, nil
+	}}
+	opts := &Options{OutputPath: tmpfile, Quiet: true, Force: true, Count: 3, NoValidate: true}
+
+	// WHEN
+	saved, err := SaveImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("SaveImageWithClient failed: %v", err)
+	}
+	want := &SavedImage{
+		Filename: tmpfile,
+		ImageID:  "1025",
+		FinalURL: "https://fastly.picsum.photos/id/1025/200/200.jpg",
+		SHA256:   "de7030234493a8bea844dbe1d8676e68a2c1a4b014c721f0425a22b6df66faec",
+	}
+	if *saved != *want {
+		t.Errorf("SaveImageWithClient() = %+v, want %+v", saved, want)
+	}
+	if len(client.urls) != 1 {
+		t.Errorf("Expected a single download ignoring --count, got %v", client.urls)
+	}
+}
+
+func TestProcessImageWithClient_Batch(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) { return okResponse(url) }}
+	opts := &Options{
+		OutputPath:  filepath.Join(dir, "img.jpg"),
+		Quiet:       true,
+		Force:       true,
+		Count:       3,
+		Concurrency: 2,
+		NoValidate:  true,
+	}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"100"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	if len(client.urls) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(client.urls))
+	}
+	for _, name := range []string{"img_1.jpg", "img_2.jpg", "img_3.jpg"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Expected %s to be created: %v", name, err)
+			continue
+		}
+		if !strings.HasPrefix(string(data), "https://picsum.photos/100?random=") {
+			t.Errorf("Unexpected request URL %q for %s", string(data), name)
+		}
+	}
+}
+
+func TestProcessImageWithClient_BatchPartialFailure(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		if strings.HasSuffix(url, "random=2") {
+			return nil, errors.New("connection reset")
+		}
+		return okResponse(url)
+	}}
+	opts := &Options{
+		OutputPath: filepath.Join(dir, "img.jpg"),
+		Quiet:      true,
+		Force:      true,
+		Count:      3,
+		NoValidate: true,
+	}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"100"}, opts)
+
+	// THEN
+	if err == nil {
+		t.Fatal("Expected error for partial batch failure, got nil")
+	}
+	if !strings.Contains(err.Error(), "1 of 3 downloads failed") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+	if len(client.urls) != 3 {
+		t.Errorf("Expected all 3 workers to run, got %d requests", len(client.urls))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "img_3.jpg")); err != nil {
+		t.Errorf("Expected img_3.jpg to be saved despite failure: %v", err)
+	}
+}
+
+func TestProcessImageWithClient_Metadata(t *testing.T) {
+	// GIVEN
+	tmpfile := filepath.Join(t.TempDir(), "meta.jpg")
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		if strings.HasSuffix(url, "/id/42/info") {
+			return okResponse(`{"id":"42","author":"Jane Doe","url":"https://unsplash.com/photos/x"}`)
+		}
+		resp, _ := okResponse("image bytes")
+		resp.Header = http.Header{"Picsum-Id": []string{"42"}, "Content-Type": []string{"image/jpeg"}}
+		return resp, nil
+	}}
+	opts := &Options{OutputPath: tmpfile, Quiet: true, Force: true, Metadata: true, NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	data, err := os.ReadFile(tmpfile + ".json")
+	if err != nil {
+		t.Fatalf("Expected sidecar to be written: %v", err)
+	}
+	for _, want := range []string{
+		`"request_url": "https://picsum.photos/200"`,
+		`"picsum_id": "42"`,
+		`"author": "Jane Doe"`,
+		`"content_length": 11`,
+		`"Content-Type": "image/jpeg"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected sidecar to contain %s, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestProcessImageWithClient_ChecksumFile(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	sums := filepath.Join(dir, "SHA256SUMS")
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) { return okResponse("image bytes") }}
+	opts := &Options{OutputPath: filepath.Join(dir, "img.jpg"), Quiet: true, Force: true, Count: 2, ChecksumFile: sums, NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	data, err := os.ReadFile(sums)
+	if err != nil {
+		t.Fatalf("Expected checksum file to be written: %v", err)
+	}
+	sum := "de7030234493a8bea844dbe1d8676e68a2c1a4b014c721f0425a22b6df66faec"
+	for _, name := range []string{"img_1.jpg", "img_2.jpg"} {
+		if !strings.Contains(string(data), sum+"  "+filepath.Join(dir, name)+"\n") {
+			t.Errorf("Expected checksum file to contain %s, got:\n%s", name, data)
+		}
+	}
+}
+
+func TestProcessImageWithClient_Format(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	contentType := "image/webp"
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		resp, _ := okResponse(url)
+		resp.Header = http.Header{"Content-Type": []string{contentType}}
+		return resp, nil
+	}}
+	opts := &Options{ImageID: "237", Format: "webp", Quiet: true, NameTemplate: filepath.Join(dir, "{id}.{ext}"), NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	if client.urls[0] != "https://picsum.photos/id/237/200.webp" {
+		t.Errorf("Expected the webp variant to be requested, got %s", client.urls[0])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "237.webp")); err != nil {
+		t.Errorf("Expected 237.webp to be saved: %v", err)
+	}
+
+	// WHEN the server answers with another format
+	contentType = "image/jpeg"
+	opts.NameTemplate = filepath.Join(dir, "mismatch.{ext}")
+	err = ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	// THEN
+	if err == nil || err.Error() != `server returned Content-Type "image/jpeg", expected image/webp` {
+		t.Errorf("Expected a Content-Type mismatch, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "mismatch.webp")); statErr == nil {
+		t.Error("Expected nothing to be saved on a Content-Type mismatch")
+	}
+}
+
+func TestProcessImageWithClient_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		filename string
+		format   string
+	}{
+		{"output extension", Options{OutputPath: "hero.png"}, "hero.png", "png"},
+		{"convert flag", Options{Convert: "gif", NameTemplate: "{id}.{ext}"}, "237.gif", "gif"},
+		{"quality only", Options{Quality: 40}, "id_237_64.jpg", "jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			t.Chdir(t.TempDir())
+			opts := tt.opts
+			opts.ImageID = "237"
+			opts.Provider = provider.Local
+			opts.Quiet = true
+			opts.ChecksumFile = "SHA256SUMS"
+
+			// WHEN
+			err := ProcessImageWithClient(context.Background(), synth.NewClient(urlbuilder.DefaultBaseURL), []string{"64"}, &opts)
+
+			// THEN
+			if err != nil {
+				t.Fatalf("ProcessImageWithClient failed: %v", err)
+			}
+			file, err := os.Open(tt.filename)
+			if err != nil {
+				t.Fatalf("Expected %s to be saved: %v", tt.filename, err)
+			}
+			defer func() { _ = file.Close() }()
+			if _, format, err := image.DecodeConfig(file); err != nil || format != tt.format {
+				t.Errorf("Expected a %s image, got %q (%v)", tt.format, format, err)
+			}
+			if err := checksum.Check(tt.filename, readChecksum(t, "SHA256SUMS")); err != nil {
+				t.Errorf("Expected the checksum of the converted file: %v", err)
+			}
+		})
+	}
+}
+
+// readChecksum returns the digest of the only entry of a checksum file
+func readChecksum(t *testing.T, path string) string {
+	t.Helper()
+	entries, err := checksum.Read(path)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one checksum entry, got %v (%v)", entries, err)
+	}
+	return entries[0].SHA256
+}
+
+func TestProcessImageWithClient_ConvertWebP(t *testing.T) {
+	// GIVEN a 1x1 lossless WebP image
+	webp := "RIFF\x1a\x00\x00\x00WEBPVP8L\r\x00\x00\x00/\x00\x00\x00\x10\a\x10\x11\x11\x88\x88\xfe\a\x00"
+	client := &mockClient{GetFunc: func(_ string) (*http.Response, error) {
+		resp, _ := okResponse(webp)
+		resp.Header = http.Header{"Content-Type": []string{"image/webp"}}
+		return resp, nil
+	}}
+	dir := t.TempDir()
+	opts := &Options{ImageID: "237", Format: "webp", Resize: "2x2", Filter: "invert", Quiet: true, OutputPath: filepath.Join(dir, "hero.png")}
+	if err := ValidateOptions(opts); err != nil {
+		t.Fatalf("ValidateOptions failed: %v", err)
+	}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"1"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	file, err := os.Open(opts.OutputPath)
+	if err != nil {
+		t.Fatalf("Expected hero.png to be saved: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	if config, format, err := image.DecodeConfig(file); err != nil || format != "png" || config.Width != 2 || config.Height != 2 {
+		t.Errorf("Expected a 2x2 png image, got %q %dx%d (%v)", format, config.Width, config.Height, err)
+	}
+}
+
+func TestProcessImageWithClient_ConvertNotAnImage(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	client := &mockClient{GetFunc: okResponse}
+	opts := &Options{Quiet: true, OutputPath: filepath.Join(dir, "hero.png"), NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	// THEN
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to decode image") {
+		t.Errorf("Expected a decode error, got %v", err)
+	}
+	if _, statErr := os.Stat(opts.OutputPath); statErr == nil {
+		t.Error("Expected nothing to be saved when the conversion fails")
+	}
+}
+
+func TestProcessImageWithClient_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "not an image",
+			args:    []string{"64"},
+			body:    "<html>Please log in</html>",
+			wantErr: `downloaded content is not an image (Content-Type "text/html"), use --no-validate to save it anyway`,
+		},
+		{
+			name:    "other size",
+			args:    []string{"64", "32"},
+			wantErr: "downloaded image is 64x64, expected 64x32, use --no-validate to save it anyway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			filename := filepath.Join(t.TempDir(), "hero.jpg")
+			client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+				if tt.body != "" {
+					resp, _ := okResponse(tt.body)
+					resp.Header = http.Header{"Content-Type": []string{"text/html"}}
+					return resp, nil
+				}
+				return synth.NewClient(urlbuilder.DefaultBaseURL).Get(context.Background(), "https://picsum.photos/64")
+			}}
+			opts := &Options{OutputPath: filename, Quiet: true}
+
+			// WHEN
+			err := ProcessImageWithClient(context.Background(), client, tt.args, opts)
+
+			// THEN
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected %q, got %v", tt.wantErr, err)
+			}
+			if _, statErr := os.Stat(filename); statErr == nil {
+				t.Error("Expected nothing to be saved when the validation fails")
+			}
+
+			// WHEN validation is skipped
+			opts.NoValidate = true
+			if err := ProcessImageWithClient(context.Background(), client, tt.args, opts); err != nil {
+				t.Fatalf("ProcessImageWithClient with NoValidate failed: %v", err)
+			}
+
+			// THEN
+			if _, err := os.Stat(filename); err != nil {
+				t.Errorf("Expected the download to be saved with NoValidate: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessImageWithClient_Transform(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		filename string
+		format   string
+		width    int
+		height   int
+	}{
+		{"resize keeps the format", Options{Resize: "120x30"}, "id_237_64x48.jpg", "jpeg", 120, 30},
+		{"crop", Options{Crop: "32x16+8+8"}, "id_237_64x48.jpg", "jpeg", 32, 16},
+		{"contain and convert", Options{Crop: "40x40+0+0", Resize: "90x60", Fit: "contain", PadColor: "#000", OutputPath: "hero.png"}, "hero.png", "png", 90, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			t.Chdir(t.TempDir())
+			opts := tt.opts
+			opts.ImageID = "237"
+			opts.Provider = provider.Local
+			opts.Quiet = true
+
+			// WHEN
+			err := ProcessImageWithClient(context.Background(), synth.NewClient(urlbuilder.DefaultBaseURL), []string{"64", "48"}, &opts)
+
+			// THEN
+			if err != nil {
+				t.Fatalf("ProcessImageWithClient failed: %v", err)
+			}
+			file, err := os.Open(tt.filename)
+			if err != nil {
+				t.Fatalf("Expected %s to be saved: %v", tt.filename, err)
+			}
+			defer func() { _ = file.Close() }()
+			config, format, err := image.DecodeConfig(file)
+			if err != nil || format != tt.format || config.Width != tt.width || config.Height != tt.height {
+				t.Errorf("Expected a %dx%d %s image, got %dx%d %q (%v)", tt.width, tt.height, tt.format, config.Width, config.Height, format, err)
+			}
+		})
+	}
+}
+
+func TestProcessImageWithClient_CropOutOfBounds(t *testing.T) {
+	// GIVEN
+	filename := filepath.Join(t.TempDir(), "hero.jpg")
+	opts := &Options{ImageID: "237", Provider: provider.Local, Quiet: true, OutputPath: filename, Crop: "64x64+0+0"}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), synth.NewClient(urlbuilder.DefaultBaseURL), []string{"64", "48"}, opts)
+
+	// THEN
+	if err == nil || err.Error() != "crop 64x64+0+0 exceeds the 64x48 image" {
+		t.Errorf("Expected an out of bounds error, got %v", err)
+	}
+	if _, statErr := os.Stat(filename); statErr == nil {
+		t.Error("Expected nothing to be saved when the crop fails")
+	}
+}
+
+func TestProcessImageWithClient_FilterIsDeterministic(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	download := func(name, filter string) []byte {
+		t.Helper()
+		opts := &Options{ImageID: "237", Provider: provider.Local, Quiet: true, OutputPath: filepath.Join(dir, name), Filter: filter}
+		if err := ProcessImageWithClient(context.Background(), synth.NewClient(urlbuilder.DefaultBaseURL), []string{"64", "48"}, opts); err != nil {
+			t.Fatalf("ProcessImageWithClient failed: %v", err)
+		}
+		data, err := os.ReadFile(opts.OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// WHEN
+	plain := download("plain.jpg", "")
+	first := download("first.jpg", "sepia,tint=#336699,contrast=0.8")
+	second := download("second.jpg", "sepia,tint=#336699,contrast=0.8")
+
+	// THEN
+	if !bytes.Equal(first, second) {
+		t.Error("Expected the same filters to give the same bytes")
+	}
+	if bytes.Equal(first, plain) {
+		t.Error("Expected the filters to change the image")
+	}
+}
+
+func TestProcessImageWithClient_ZeroCountDownloadsOneImage(t *testing.T) {
+	// GIVEN
+	t.Chdir(t.TempDir())
+	opts := &Options{ImageID: "237", Provider: provider.Local, Quiet: true, Count: 0, Concurrency: 0}
+	if err := ValidateOptions(opts); err != nil {
+		t.Fatalf("ValidateOptions failed: %v", err)
+	}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), synth.NewClient(urlbuilder.DefaultBaseURL), []string{"64"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	if entries, _ := os.ReadDir("."); len(entries) != 1 || entries[0].Name() != "id_237_64.jpg" {
+		t.Errorf("Expected a single id_237_64.jpg, got %v", entries)
+	}
+}
+
+func TestProcessImageWithClient_IDPlaceholderAndPrintID(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		resp, _ := okResponse(url)
+		resp.Header = http.Header{"Picsum-Id": []string{"1025"}}
+		return resp, nil
+	}}
+	opts := &Options{OutputPath: filepath.Join(dir, "pic_{id}.jpg"), Quiet: true, Force: true, PrintID: true, NoValidate: true}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+	printed, _ := io.ReadAll(r)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pic_1025.jpg")); err != nil {
+		t.Errorf("Expected pic_1025.jpg to be created: %v", err)
+	}
+	if string(printed) != "1025\n" {
+		t.Errorf("Expected printed ID %q, got %q", "1025\n", string(printed))
+	}
+}
+
+func TestProcessImageWithClient_NameTemplate(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		if strings.HasSuffix(url, "/id/42/info") {
+			return okResponse(`{"id":"42","author":"Jane Doe"}`)
+		}
+		resp, _ := okResponse(url)
+		resp.Header = http.Header{"Picsum-Id": []string{"42"}}
+		return resp, nil
+	}}
+	opts := &Options{
+		NameTemplate: filepath.Join(dir, "{author}", "{seed}", "{id}_{width}x{height}_{gray}.{ext}"),
+		Seed:         "hello",
+		Grayscale:    true,
+		Quiet:        true,
+		Force:        true,
+		NoValidate:   true,
+	}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200", "300"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	expected := filepath.Join(dir, "Jane Doe", "hello", "42_200x300_gray.jpg")
+	if _, err := os.Stat(expected); err != nil {
+		t.Errorf("Expected %s to be created: %v", expected, err)
+	}
+}
+
+func TestProcessImageWithClient_BatchNameTemplate(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) { return okResponse(url) }}
+	opts := &Options{
+		NameTemplate: filepath.Join(dir, "set", "img-{n}.{ext}"),
+		Quiet:        true,
+		Force:        true,
+		Count:        2,
+		NoValidate:   true,
+	}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"100"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	for _, name := range []string{"img-1.jpg", "img-2.jpg"} {
+		if _, err := os.Stat(filepath.Join(dir, "set", name)); err != nil {
+			t.Errorf("Expected %s to be created: %v", name, err)
+		}
+	}
+}
+
+func TestProcessImageWithClient_StdoutWithPrintID(t *testing.T) {
+	// GIVEN
+	client := &mockClient{GetFunc: func(_ string) (*http.Response, error) {
+		resp, _ := okResponse("jpeg bytes")
+		resp.Header = http.Header{"Picsum-Id": []string{"99"}}
+		return resp, nil
+	}}
+	opts := &Options{OutputPath: "-", PrintID: true, NoValidate: true}
+	if err := ValidateOptions(opts); err != nil {
+		t.Fatalf("ValidateOptions failed: %v", err)
+	}
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	rOut, wOut, _ := os.Pipe()
+	rErr, wErr, _ := os.Pipe()
+	os.Stdout, os.Stderr = wOut, wErr
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	_ = wOut.Close()
+	_ = wErr.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	stdout, _ := io.ReadAll(rOut)
+	stderr, _ := io.ReadAll(rErr)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	if string(stdout) != "jpeg bytes" {
+		t.Errorf("Expected only the image on stdout, got %q", string(stdout))
+	}
+	if string(stderr) != "99\n" {
+		t.Errorf("Expected the image ID on stderr, got %q", string(stderr))
+	}
+}
+
+func TestProcessImageWithClient_ContextPassedToClient(t *testing.T) {
+	// GIVEN
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := &mockClient{}
+	client.GetFunc = func(_ string) (*http.Response, error) {
+		return nil, client.ctx.Err()
+	}
+	opts := &Options{OutputPath: filepath.Join(t.TempDir(), "cancelled.jpg"), Quiet: true, Force: true}
+
+	// WHEN
+	err := ProcessImageWithClient(ctx, client, []string{"200"}, opts)
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{"plain", &Options{NoCache: true}, "*httpclient.DefaultClient"},
+		{"retries", &Options{NoCache: true, Retries: 2}, "*httpclient.RetryingClient"},
+		{"cache", &Options{CacheDir: t.TempDir()}, "*cache.Client"},
+		{"default cache dir", &Options{}, "*cache.Client"},
+		{"local provider", &Options{Provider: provider.Local}, "*synth.Client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tt.opts)
+			if err != nil {
+				t.Fatalf("NewHTTPClient failed: %v", err)
+			}
+			if got := fmt.Sprintf("%T", client); got != tt.want {
+				t.Errorf("NewHTTPClient() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessImageWithClient_TemplatedProvider(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	config := filepath.Join(dir, "providers.json")
+	content := `{"providers": {"corp": {"url": "https://assets.example.com/{id}/{width}x{height}", "filename": "` + filepath.ToSlash(dir) + `/corp_{id}.{ext}"}}}`
+	if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) { return okResponse(url) }}
+	opts := &Options{ImageID: "42", Quiet: true, Force: true, Provider: "corp", ProviderConfig: config, NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200", "300"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "corp_42.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if string(data) != "https://assets.example.com/42/200x300" {
+		t.Errorf("Unexpected file content %q", string(data))
+	}
+}
+
+func TestProcessImageWithClient_TemplatedProviderBatchKeepsURL(t *testing.T) {
+	// GIVEN a template with a query of its own
+	dir := t.TempDir()
+	config := filepath.Join(dir, "providers.json")
+	content := `{"providers": {"corp": {"url": "https://assets.example.com/{width}x{height}?sig=abc", "filename": "` + filepath.ToSlash(dir) + `/corp.{ext}"}}}`
+	if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var mu sync.Mutex
+	var urls []string
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) {
+		mu.Lock()
+		urls = append(urls, url)
+		mu.Unlock()
+		return okResponse(url)
+	}}
+	opts := &Options{Count: 2, Quiet: true, Force: true, Provider: "corp", ProviderConfig: config, NoValidate: true}
+
+	// WHEN
+	err := ProcessImageWithClient(context.Background(), client, []string{"200", "300"}, opts)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ProcessImageWithClient failed: %v", err)
+	}
+	for _, url := range urls {
+		if url != "https://assets.example.com/200x300?sig=abc" {
+			t.Errorf("Expected the templated URL unchanged, got %q", url)
+		}
+	}
+}
+
+func TestProcessImageWithClient_UnknownProvider(t *testing.T) {
+	client := &mockClient{GetFunc: func(url string) (*http.Response, error) { return okResponse(url) }}
+	opts := &Options{Provider: "corp", ProviderConfig: filepath.Join(t.TempDir(), "missing.json")}
+
+	err := ProcessImageWithClient(context.Background(), client, []string{"200"}, opts)
+
+	if err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Errorf("Expected unknown provider error, got %v", err)
+	}
+	if len(client.urls) != 0 {
+		t.Errorf("Expected no request, got %v", client.urls)
+	}
+}
+
+func TestTargetFilenames(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		opts *Options
+		want []string
+	}{
+		{"default name", []string{"200"}, &Options{ImageID: "237"}, []string{"id_237_200.jpg"}},
+		{"output path", []string{"200"}, &Options{OutputPath: "hero.jpg"}, []string{"hero.jpg"}},
+		{"template without placeholders", []string{"200"}, &Options{NameTemplate: "img.jpg"}, []string{"img.jpg"}},
+		{"template with requested id", []string{"200"}, &Options{ImageID: "7", NameTemplate: "{id}_{width}.{ext}"}, []string{"7_200.jpg"}},
+		{"template with served id", []string{"200"}, &Options{NameTemplate: "{id}.jpg"}, nil},
+		{"template with author", []string{"200"}, &Options{ImageID: "7", NameTemplate: "{author}.jpg"}, nil},
+		{"converted default name", []string{"200"}, &Options{Seed: "a", Convert: "png"}, []string{"seed_a_200.png"}},
+		{"batch", []string{"200"}, &Options{Count: 2}, []string{"200_1.jpg", "200_2.jpg"}},
+		{"batch template with n", []string{"200"}, &Options{Count: 2, NameTemplate: "img-{n}.jpg"}, []string{"img-1.jpg", "img-2.jpg"}},
+		{"batch template without n", []string{"200"}, &Options{Count: 2, NameTemplate: "img.jpg"}, []string{"img_1.jpg", "img_2.jpg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TargetFilenames(tt.args, tt.opts)
+			if err != nil {
+				t.Fatalf("TargetFilenames failed: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("TargetFilenames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Package batch to run a number of jobs through a bounded worker pool
+*/
+package batch
+
+import "sync"
+
+// DefaultConcurrency is the number of workers used when none is specified
+const DefaultConcurrency = 4
+
+/*
+Run executes job for every index in [0, n) using at most concurrency workers.
+It waits for all jobs to finish and returns one error slot per index,
+nil for the jobs that succeeded.
+*/
+func Run(n, concurrency int, job func(index int) error) []error {
+	errs := make([]error, n)
+	if n <= 0 {
+		return errs
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = job(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
+
+// CountFailed returns the number of non-nil errors
+func CountFailed(errs []error) int {
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
+}
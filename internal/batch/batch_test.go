@@ -0,0 +1,98 @@
+package batch
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun_AllJobsExecuted(t *testing.T) {
+	// GIVEN
+	var calls int32
+	seen := make([]int32, 10)
+
+	// WHEN
+	errs := Run(10, 3, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		atomic.AddInt32(&seen[i], 1)
+		return nil
+	})
+
+	// THEN
+	if len(errs) != 10 {
+		t.Fatalf("Expected 10 error slots, got %d", len(errs))
+	}
+	if calls != 10 {
+		t.Errorf("Expected 10 calls, got %d", calls)
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Errorf("Expected index %d to run once, ran %d times", i, n)
+		}
+	}
+	if CountFailed(errs) != 0 {
+		t.Errorf("Expected no failures, got %d", CountFailed(errs))
+	}
+}
+
+func TestRun_ReportsFailuresPerIndex(t *testing.T) {
+	// WHEN
+	errs := Run(5, 2, func(i int) error {
+		if i%2 == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	// THEN
+	for i, err := range errs {
+		if (err != nil) != (i%2 == 1) {
+			t.Errorf("Unexpected error state at index %d: %v", i, err)
+		}
+	}
+	if CountFailed(errs) != 2 {
+		t.Errorf("Expected 2 failures, got %d", CountFailed(errs))
+	}
+}
+
+func TestRun_RespectsConcurrencyLimit(t *testing.T) {
+	// GIVEN
+	var running, peak int32
+
+	// WHEN
+	Run(12, 3, func(_ int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+
+	// THEN
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent jobs, got %d", peak)
+	}
+}
+
+func TestRun_ZeroJobs(t *testing.T) {
+	errs := Run(0, 4, func(_ int) error {
+		t.Error("job should not be called")
+		return nil
+	})
+	if len(errs) != 0 {
+		t.Errorf("Expected no error slots, got %d", len(errs))
+	}
+}
+
+func TestRun_DefaultConcurrency(t *testing.T) {
+	errs := Run(3, 0, func(_ int) error { return nil })
+	if CountFailed(errs) != 0 {
+		t.Errorf("Expected no failures, got %d", CountFailed(errs))
+	}
+}
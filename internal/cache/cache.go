@@ -0,0 +1,243 @@
+/*
+Package cache to keep deterministic responses on disk for offline and repeatable runs
+*/
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File extensions of the two files that make up a cache entry
+const (
+	entryExt = ".json"
+	bodyExt  = ".body"
+)
+
+// Entry describes a cached response, stored next to its body
+type Entry struct {
+	URL      string      `json:"url"`
+	FinalURL string      `json:"final_url,omitempty"`
+	Header   http.Header `json:"header"`
+	Size     int64       `json:"size"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Cache is a directory of responses keyed by request URL
+type Cache struct {
+	dir string
+}
+
+// New creates a Cache stored in dir, the directory is created on the first store
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the picsum directory inside the user cache directory, $XDG_CACHE_HOME on Linux
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %v", err)
+	}
+	return filepath.Join(dir, "picsum"), nil
+}
+
+// Dir returns the directory the cache is stored in
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key returns the name of the cache entry of rawURL
+func Key(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+Cacheable reports whether rawURL always returns the same bytes: only image
+and info URLs under baseURL selected by --id or --seed qualify, random
+picks never do
+*/
+func Cacheable(baseURL, rawURL string) bool {
+	rest, ok := strings.CutPrefix(rawURL, baseURL+"/")
+	if !ok {
+		return false
+	}
+	u, err := url.Parse(rest)
+	if err != nil || u.Query().Has("random") {
+		return false
+	}
+	return strings.HasPrefix(u.Path, "id/") || strings.HasPrefix(u.Path, "seed/")
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+entryExt)
+}
+
+func (c *Cache) bodyPath(key string) string {
+	return filepath.Join(c.dir, key+bodyExt)
+}
+
+/*
+Lookup returns the cached response of rawURL, or false when there is no
+complete entry. A hit marks the entry as recently used.
+*/
+func (c *Cache) Lookup(rawURL string) (*http.Response, bool) {
+	key := Key(rawURL)
+	entry, err := readEntry(c.entryPath(key))
+	if err != nil || entry.URL != rawURL {
+		return nil, false
+	}
+
+	body, err := os.Open(c.bodyPath(key))
+	if err != nil {
+		return nil, false
+	}
+	if stat, err := body.Stat(); err != nil || stat.Size() != entry.Size {
+		_ = body.Close()
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(c.bodyPath(key), now, now)
+
+	requestURL := entry.FinalURL
+	if requestURL == "" {
+		requestURL = entry.URL
+	}
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		_ = body.Close()
+		return nil, false
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          body,
+		ContentLength: entry.Size,
+		Request:       req,
+	}, true
+}
+
+/*
+Store wraps the body of resp so that it is written to the cache as it is read.
+The entry is only committed once the whole body has been read; closing the
+body early discards it.
+*/
+func (c *Cache) Store(rawURL string, resp *http.Response) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, ".*"+bodyExt+".tmp")
+	if err != nil {
+		return
+	}
+
+	entry := Entry{URL: rawURL, Header: resp.Header.Clone()}
+	entry.Header.Del("Set-Cookie")
+	if resp.Request != nil && resp.Request.URL != nil {
+		entry.FinalURL = resp.Request.URL.String()
+	}
+	resp.Body = &storingBody{ReadCloser: resp.Body, cache: c, entry: entry, tmp: tmp}
+}
+
+// storingBody copies the bytes read from a response body to a temporary file
+type storingBody struct {
+	io.ReadCloser
+	cache *Cache
+	entry Entry
+	tmp   *os.File
+}
+
+func (b *storingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.tmp != nil && n > 0 {
+		if _, werr := b.tmp.Write(p[:n]); werr != nil {
+			b.discard()
+		}
+		b.entry.Size += int64(n)
+	}
+	if b.tmp != nil && err == io.EOF {
+		b.commit()
+	}
+	return n, err
+}
+
+func (b *storingBody) Close() error {
+	b.discard()
+	return b.ReadCloser.Close()
+}
+
+// commit moves the complete body into place and then records the entry that makes it visible
+func (b *storingBody) commit() {
+	tmp := b.tmp
+	b.tmp = nil
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	key := Key(b.entry.URL)
+	if err := os.Rename(tmp.Name(), b.cache.bodyPath(key)); err != nil {
+		return
+	}
+	b.entry.StoredAt = time.Now().UTC()
+	_ = writeEntry(b.cache.entryPath(key), b.entry)
+}
+
+// discard drops a partially read body
+func (b *storingBody) discard() {
+	if b.tmp == nil {
+		return
+	}
+	_ = b.tmp.Close()
+	_ = os.Remove(b.tmp.Name())
+	b.tmp = nil
+}
+
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// writeEntry writes the entry through a temporary file so readers never see a partial record
+func writeEntry(path string, entry Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".*"+entryExt+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// newResponse creates a 200 response for rawURL that was redirected to finalURL
+func newResponse(t *testing.T, finalURL, body string) *http.Response {
+	t.Helper()
+	u, err := url.Parse(finalURL)
+	if err != nil {
+		t.Fatalf("invalid URL: %v", err)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Picsum-Id": {"237"}, "Content-Type": {"image/jpeg"}, "Set-Cookie": {"x=1"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    &http.Request{URL: u},
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://picsum.photos/id/237/300", true},
+		{"https://picsum.photos/seed/picsum/200/300?grayscale", true},
+		{"https://picsum.photos/id/237/info", true},
+		{"https://picsum.photos/300", false},
+		{"https://picsum.photos/300?random=2", false},
+		{"https://picsum.photos/v2/list?page=1&limit=30", false},
+		{"https://example.com/id/237/300", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := Cacheable(urlbuilder.DefaultBaseURL, tt.url); got != tt.want {
+				t.Errorf("Cacheable(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreAndLookup(t *testing.T) {
+	// GIVEN
+	c := New(filepath.Join(t.TempDir(), "cache"))
+	rawURL := "https://picsum.photos/id/237/300"
+	resp := newResponse(t, "https://fastly.picsum.photos/id/237/300/300.jpg", "image bytes")
+
+	// WHEN
+	c.Store(rawURL, resp)
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	cached, ok := c.Lookup(rawURL)
+
+	// THEN
+	if string(data) != "image bytes" {
+		t.Errorf("Expected the body to pass through unchanged, got %q", data)
+	}
+	if !ok {
+		t.Fatal("Expected a cache hit after the body was read")
+	}
+	defer func() { _ = cached.Body.Close() }()
+	body, _ := io.ReadAll(cached.Body)
+	if string(body) != "image bytes" {
+		t.Errorf("Expected cached body %q, got %q", "image bytes", body)
+	}
+	if cached.StatusCode != http.StatusOK || cached.ContentLength != int64(len("image bytes")) {
+		t.Errorf("Unexpected cached response: %d, length %d", cached.StatusCode, cached.ContentLength)
+	}
+	if cached.Header.Get("Picsum-ID") != "237" || cached.Header.Get("Set-Cookie") != "" {
+		t.Errorf("Unexpected cached headers: %v", cached.Header)
+	}
+	if cached.Request.URL.String() != "https://fastly.picsum.photos/id/237/300/300.jpg" {
+		t.Errorf("Expected the final URL to be restored, got %s", cached.Request.URL)
+	}
+}
+
+func TestStore_PartialBodyIsDiscarded(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	c := New(dir)
+	rawURL := "https://picsum.photos/id/237/300"
+	resp := newResponse(t, rawURL, "image bytes")
+
+	// WHEN
+	c.Store(rawURL, resp)
+	_, _ = resp.Body.Read(make([]byte, 3))
+	_ = resp.Body.Close()
+
+	// THEN
+	if _, ok := c.Lookup(rawURL); ok {
+		t.Error("Expected no cache hit for a partially read body")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no files left in the cache, got %d", len(entries))
+	}
+}
+
+func TestLookup_Miss(t *testing.T) {
+	c := New(t.TempDir())
+	if _, ok := c.Lookup("https://picsum.photos/id/1/300"); ok {
+		t.Error("Expected a cache miss in an empty cache")
+	}
+}
+
+func TestLookup_TruncatedBodyIsAMiss(t *testing.T) {
+	// GIVEN
+	c := New(t.TempDir())
+	rawURL := "https://picsum.photos/id/237/300"
+	resp := newResponse(t, rawURL, "image bytes")
+	c.Store(rawURL, resp)
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	// WHEN
+	if err := os.Truncate(c.bodyPath(Key(rawURL)), 2); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	// THEN
+	if _, ok := c.Lookup(rawURL); ok {
+		t.Error("Expected a cache miss for a truncated body")
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+	t.Setenv("HOME", "/tmp/home")
+
+	dir, err := DefaultDir()
+	if err != nil {
+		t.Fatalf("DefaultDir failed: %v", err)
+	}
+	if filepath.Base(dir) != "picsum" {
+		t.Errorf("Expected a picsum directory, got %s", dir)
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+)
+
+// Client implements httpclient.Getter by answering deterministic requests from a Cache
+type Client struct {
+	inner   httpclient.Getter
+	cache   *Cache
+	baseURL string
+	offline bool
+}
+
+/*
+NewClient wraps inner so that cacheable URLs under baseURL are served from
+cache when present and stored in it after a successful fetch. In offline mode every
+request that cannot be answered from cache fails instead of reaching inner.
+*/
+func NewClient(inner httpclient.Getter, cache *Cache, baseURL string, offline bool) httpclient.Getter {
+	return &Client{inner: inner, cache: cache, baseURL: baseURL, offline: offline}
+}
+
+// Get performs an HTTP GET request, consulting the cache first
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	cacheable := Cacheable(c.baseURL, url)
+	if cacheable {
+		if resp, ok := c.cache.Lookup(url); ok {
+			return resp, nil
+		}
+	}
+	if c.offline {
+		return nil, fmt.Errorf("%s is not in the cache and offline mode is enabled", url)
+	}
+
+	resp, err := c.inner.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable && resp.StatusCode == http.StatusOK {
+		c.cache.Store(url, resp)
+	}
+	return resp, nil
+}
+
+/*
+Head answers a HEAD request from the headers of the cache entry when present,
+otherwise from inner without storing anything
+*/
+func (c *Client) Head(ctx context.Context, url string) (*http.Response, error) {
+	if Cacheable(c.baseURL, url) {
+		if resp, ok := c.cache.Lookup(url); ok {
+			_ = resp.Body.Close()
+			resp.Body = http.NoBody
+			return resp, nil
+		}
+	}
+	if c.offline {
+		return nil, fmt.Errorf("%s is not in the cache and offline mode is enabled", url)
+	}
+	return httpclient.Head(ctx, c.inner, url)
+}
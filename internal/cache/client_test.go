@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// countingClient serves a fixed body and counts the requests that reach it
+type countingClient struct {
+	calls  int
+	status int
+}
+
+func (c *countingClient) Get(_ context.Context, rawURL string) (*http.Response, error) {
+	c.calls++
+	status := c.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	u, _ := url.Parse(rawURL)
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("image bytes")),
+		Request:    &http.Request{URL: u},
+	}, nil
+}
+
+// fetch reads the whole body of rawURL through client
+func fetch(t *testing.T, client httpclient.Getter, rawURL string) (string, error) {
+	t.Helper()
+	resp, err := client.Get(context.Background(), rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestClient_ServesDeterministicURLsFromCache(t *testing.T) {
+	// GIVEN
+	inner := &countingClient{}
+	client := NewClient(inner, New(t.TempDir()), urlbuilder.DefaultBaseURL, false)
+
+	// WHEN
+	first, err1 := fetch(t, client, "https://picsum.photos/id/237/300")
+	second, err2 := fetch(t, client, "https://picsum.photos/id/237/300")
+
+	// THEN
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Unexpected errors: %v, %v", err1, err2)
+	}
+	if first != "image bytes" || second != "image bytes" {
+		t.Errorf("Unexpected bodies %q, %q", first, second)
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 network request, got %d", inner.calls)
+	}
+}
+
+func TestClient_NeverCachesRandomURLs(t *testing.T) {
+	// GIVEN
+	inner := &countingClient{}
+	client := NewClient(inner, New(t.TempDir()), urlbuilder.DefaultBaseURL, false)
+
+	// WHEN
+	_, _ = fetch(t, client, "https://picsum.photos/300")
+	_, _ = fetch(t, client, "https://picsum.photos/300")
+
+	// THEN
+	if inner.calls != 2 {
+		t.Errorf("Expected 2 network requests, got %d", inner.calls)
+	}
+}
+
+func TestClient_DoesNotCacheErrors(t *testing.T) {
+	// GIVEN
+	inner := &countingClient{status: http.StatusNotFound}
+	client := NewClient(inner, New(t.TempDir()), urlbuilder.DefaultBaseURL, false)
+
+	// WHEN
+	_, _ = fetch(t, client, "https://picsum.photos/id/9999/300")
+	_, _ = fetch(t, client, "https://picsum.photos/id/9999/300")
+
+	// THEN
+	if inner.calls != 2 {
+		t.Errorf("Expected 2 network requests, got %d", inner.calls)
+	}
+}
+
+func TestClient_Offline(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	inner := &countingClient{}
+	_, _ = fetch(t, NewClient(inner, New(dir), urlbuilder.DefaultBaseURL, false), "https://picsum.photos/id/237/300")
+	offline := NewClient(inner, New(dir), urlbuilder.DefaultBaseURL, true)
+
+	// WHEN
+	cached, cachedErr := fetch(t, offline, "https://picsum.photos/id/237/300")
+	_, missErr := fetch(t, offline, "https://picsum.photos/id/1/300")
+	_, randomErr := fetch(t, offline, "https://picsum.photos/300")
+
+	// THEN
+	if cachedErr != nil || cached != "image bytes" {
+		t.Errorf("Expected cached image offline, got %q, %v", cached, cachedErr)
+	}
+	if missErr == nil || !strings.Contains(missErr.Error(), "offline") {
+		t.Errorf("Expected offline error for uncached URL, got %v", missErr)
+	}
+	if randomErr == nil {
+		t.Error("Expected offline error for random URL")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected only the initial network request, got %d", inner.calls)
+	}
+}
+
+func TestClient_Head(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	inner := &countingClient{}
+	_, _ = fetch(t, NewClient(inner, New(dir), urlbuilder.DefaultBaseURL, false), "https://picsum.photos/id/237/300")
+	client := NewClient(inner, New(dir), urlbuilder.DefaultBaseURL, false).(*Client)
+	offline := NewClient(inner, New(dir), urlbuilder.DefaultBaseURL, true).(*Client)
+
+	// WHEN
+	cached, cachedErr := client.Head(context.Background(), "https://picsum.photos/id/237/300")
+	missed, missedErr := client.Head(context.Background(), "https://picsum.photos/id/1/300")
+	_, offlineErr := offline.Head(context.Background(), "https://picsum.photos/id/1/300")
+
+	// THEN
+	if cachedErr != nil || cached.StatusCode != http.StatusOK || cached.Body != http.NoBody {
+		t.Errorf("Expected the cached headers without a body, got %v, %v", cached, cachedErr)
+	}
+	if missedErr != nil || missed.Body != http.NoBody {
+		t.Errorf("Expected the headers of the uncached URL without a body, got %v, %v", missed, missedErr)
+	}
+	if offlineErr == nil || !strings.Contains(offlineErr.Error(), "offline") {
+		t.Errorf("Expected offline error for uncached URL, got %v", offlineErr)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected the initial request and the miss to reach the network, got %d", inner.calls)
+	}
+	if _, ok := New(dir).Lookup("https://picsum.photos/id/1/300"); ok {
+		t.Error("Expected a HEAD request not to be cached")
+	}
+}
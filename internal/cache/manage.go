@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fileName matches the files of a cache entry, both in the directory and in an export
+var fileName = regexp.MustCompile(`^[0-9a-f]{64}\.(json|body)$`)
+
+// Item is a cache entry together with its key and the last time it was used
+type Item struct {
+	Entry
+	Key      string    `json:"key"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Stats summarises the content of the cache
+type Stats struct {
+	Dir        string    `json:"dir"`
+	Entries    int       `json:"entries"`
+	Size       int64     `json:"size"`
+	OldestUsed time.Time `json:"oldest_used,omitzero"`
+	NewestUsed time.Time `json:"newest_used,omitzero"`
+}
+
+// Open creates a Cache stored in dir, or in DefaultDir when dir is empty
+func Open(dir string) (*Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return New(dir), nil
+}
+
+/*
+Items returns the complete entries of the cache, most recently used first.
+A missing cache directory is an empty cache.
+*/
+func (c *Cache) Items() ([]Item, error) {
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %v", err)
+	}
+
+	var items []Item
+	for _, file := range files {
+		key, ok := strings.CutSuffix(file.Name(), entryExt)
+		if !ok || !fileName.MatchString(file.Name()) {
+			continue
+		}
+		entry, err := readEntry(c.entryPath(key))
+		if err != nil {
+			continue
+		}
+		stat, err := os.Stat(c.bodyPath(key))
+		if err != nil {
+			continue
+		}
+		items = append(items, Item{Entry: *entry, Key: key, LastUsed: stat.ModTime()})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].LastUsed.After(items[j].LastUsed) })
+	return items, nil
+}
+
+// Stats returns the number, total size and usage range of the cache entries
+func (c *Cache) Stats() (*Stats, error) {
+	items, err := c.Items()
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{Dir: c.dir, Entries: len(items)}
+	for _, item := range items {
+		stats.Size += item.Size
+	}
+	if len(items) > 0 {
+		stats.NewestUsed = items[0].LastUsed
+		stats.OldestUsed = items[len(items)-1].LastUsed
+	}
+	return stats, nil
+}
+
+/*
+Prune removes the entries not used within maxAge and then the least recently
+used entries until the cache fits in maxSize bytes. A zero limit is ignored.
+Returns the removed entries.
+*/
+func (c *Cache) Prune(maxAge time.Duration, maxSize int64, now time.Time) ([]Item, error) {
+	items, err := c.Items()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+
+	var removed []Item
+	// Walk from the least recently used entry
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		expired := maxAge > 0 && now.Sub(item.LastUsed) > maxAge
+		oversize := maxSize > 0 && total > maxSize
+		if !expired && !oversize {
+			continue
+		}
+		if err := c.remove(item.Key); err != nil {
+			return removed, err
+		}
+		total -= item.Size
+		removed = append(removed, item)
+	}
+	return removed, nil
+}
+
+/*
+Clear removes every entry of the cache, including partial writes left behind
+by interrupted runs. Returns the number of entries removed.
+*/
+func (c *Cache) Clear() (int, error) {
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache: %v", err)
+	}
+
+	count := 0
+	for _, file := range files {
+		name := file.Name()
+		temporary := strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp")
+		if !fileName.MatchString(name) && !temporary {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, name)); err != nil {
+			return count, fmt.Errorf("failed to clear cache: %v", err)
+		}
+		if strings.HasSuffix(name, entryExt) && !temporary {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// remove deletes the entry first so that a half-removed entry is never served
+func (c *Cache) remove(key string) error {
+	for _, path := range []string{c.entryPath(key), c.bodyPath(key)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cache entry: %v", err)
+		}
+	}
+	return nil
+}
+
+/*
+Export writes every entry of the cache to w as a gzip-compressed tarball
+that Import can load on another machine. Returns the number of entries written.
+*/
+func (c *Cache) Export(w io.Writer) (int, error) {
+	items, err := c.Items()
+	if err != nil {
+		return 0, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, item := range items {
+		// The body goes first so that an importer never sees an entry without it
+		for _, path := range []string{c.bodyPath(item.Key), c.entryPath(item.Key)} {
+			if err := addFile(tw, path, item.LastUsed); err != nil {
+				return 0, fmt.Errorf("failed to export cache: %v", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to export cache: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to export cache: %v", err)
+	}
+	return len(items), nil
+}
+
+func addFile(tw *tar.Writer, path string, modTime time.Time) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:    filepath.Base(path),
+		Mode:    0644,
+		Size:    stat.Size(),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+/*
+Import loads the entries of a tarball written by Export into the cache,
+replacing entries with the same key. Files that are not cache entries and
+entries whose key does not match their URL are rejected. Returns the number
+of entries imported.
+*/
+func (c *Cache) Import(r io.Reader) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import cache: %v", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to import cache: %v", err)
+	}
+
+	count := 0
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to import cache: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg || !fileName.MatchString(header.Name) {
+			return count, fmt.Errorf("failed to import cache: unexpected file %q", header.Name)
+		}
+
+		if key, ok := strings.CutSuffix(header.Name, entryExt); ok {
+			if err := c.importEntry(key, tr); err != nil {
+				return count, fmt.Errorf("failed to import cache: %v", err)
+			}
+			count++
+			continue
+		}
+		if err := c.importBody(header, tr); err != nil {
+			return count, fmt.Errorf("failed to import cache: %v", err)
+		}
+	}
+}
+
+func (c *Cache) importEntry(key string, r io.Reader) error {
+	var entry Entry
+	if err := json.NewDecoder(r).Decode(&entry); err != nil {
+		return fmt.Errorf("invalid entry %s: %v", key, err)
+	}
+	if Key(entry.URL) != key {
+		return fmt.Errorf("entry %s does not match its URL %s", key, entry.URL)
+	}
+	return writeEntry(c.entryPath(key), entry)
+}
+
+func (c *Cache) importBody(header *tar.Header, r io.Reader) error {
+	tmp, err := os.CreateTemp(c.dir, ".*"+bodyExt+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, header.Name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return os.Chtimes(path, header.ModTime, header.ModTime)
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addEntry stores body for rawURL and marks it as last used at lastUsed
+func addEntry(t *testing.T, c *Cache, rawURL, body string, lastUsed time.Time) {
+	t.Helper()
+	resp := newResponse(t, rawURL, body)
+	c.Store(rawURL, resp)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if err := os.Chtimes(c.bodyPath(Key(rawURL)), lastUsed, lastUsed); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+}
+
+// urls returns the URLs of the items in order
+func urls(items []Item) []string {
+	var result []string
+	for _, item := range items {
+		result = append(result, item.URL)
+	}
+	return result
+}
+
+func TestItems_MostRecentlyUsedFirst(t *testing.T) {
+	// GIVEN
+	c := New(t.TempDir())
+	now := time.Now()
+	addEntry(t, c, "https://picsum.photos/id/1/300", "one", now.Add(-2*time.Hour))
+	addEntry(t, c, "https://picsum.photos/id/2/300", "two!", now.Add(-time.Hour))
+
+	// WHEN
+	items, err := c.Items()
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Items failed: %v", err)
+	}
+	got := strings.Join(urls(items), " ")
+	if got != "https://picsum.photos/id/2/300 https://picsum.photos/id/1/300" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+	if items[0].Size != 4 || items[0].Key != Key("https://picsum.photos/id/2/300") {
+		t.Errorf("Unexpected item: %+v", items[0])
+	}
+}
+
+func TestItems_MissingDirectory(t *testing.T) {
+	items, err := New(filepath.Join(t.TempDir(), "missing")).Items()
+	if err != nil || len(items) != 0 {
+		t.Errorf("Expected an empty cache, got %v, %v", items, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	// GIVEN
+	c := New(t.TempDir())
+	now := time.Now().Truncate(time.Second)
+	addEntry(t, c, "https://picsum.photos/id/1/300", "one", now.Add(-time.Hour))
+	addEntry(t, c, "https://picsum.photos/id/2/300", "two!", now)
+
+	// WHEN
+	stats, err := c.Stats()
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Entries != 2 || stats.Size != 7 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if !stats.NewestUsed.Equal(now) || !stats.OldestUsed.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Unexpected usage range: %v - %v", stats.OldestUsed, stats.NewestUsed)
+	}
+}
+
+func TestPrune_ByAge(t *testing.T) {
+	// GIVEN
+	c := New(t.TempDir())
+	now := time.Now()
+	addEntry(t, c, "https://picsum.photos/id/1/300", "old", now.Add(-48*time.Hour))
+	addEntry(t, c, "https://picsum.photos/id/2/300", "new", now.Add(-time.Hour))
+
+	// WHEN
+	removed, err := c.Prune(24*time.Hour, 0, now)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if got := strings.Join(urls(removed), " "); got != "https://picsum.photos/id/1/300" {
+		t.Errorf("Unexpected removed entries: %s", got)
+	}
+	items, _ := c.Items()
+	if len(items) != 1 || items[0].URL != "https://picsum.photos/id/2/300" {
+		t.Errorf("Unexpected remaining entries: %v", urls(items))
+	}
+}
+
+func TestPrune_BySizeLeastRecentlyUsedFirst(t *testing.T) {
+	// GIVEN
+	c := New(t.TempDir())
+	now := time.Now()
+	addEntry(t, c, "https://picsum.photos/id/1/300", "0123456789", now.Add(-3*time.Hour))
+	addEntry(t, c, "https://picsum.photos/id/2/300", "0123456789", now.Add(-time.Hour))
+	addEntry(t, c, "https://picsum.photos/id/3/300", "0123456789", now.Add(-2*time.Hour))
+
+	// WHEN
+	removed, err := c.Prune(0, 15, now)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	got := strings.Join(urls(removed), " ")
+	if got != "https://picsum.photos/id/1/300 https://picsum.photos/id/3/300" {
+		t.Errorf("Unexpected removed entries: %s", got)
+	}
+	if _, ok := c.Lookup("https://picsum.photos/id/2/300"); !ok {
+		t.Error("Expected the most recently used entry to remain")
+	}
+}
+
+func TestClear(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	c := New(dir)
+	addEntry(t, c, "https://picsum.photos/id/1/300", "one", time.Now())
+	addEntry(t, c, "https://picsum.photos/id/2/300", "two", time.Now())
+	_ = os.WriteFile(filepath.Join(dir, ".abc.body.tmp"), []byte("partial"), 0600)
+	_ = os.WriteFile(filepath.Join(dir, "unrelated.txt"), []byte("keep"), 0600)
+
+	// WHEN
+	count, err := c.Clear()
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 removed entries, got %d", count)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != "unrelated.txt" {
+		t.Errorf("Expected only unrelated files to remain, got %v", files)
+	}
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	// GIVEN
+	source := New(t.TempDir())
+	lastUsed := time.Now().Add(-time.Hour).Truncate(time.Second)
+	addEntry(t, source, "https://picsum.photos/id/1/300", "one", lastUsed)
+	addEntry(t, source, "https://picsum.photos/seed/x/300", "seeded", lastUsed)
+
+	// WHEN
+	var archive bytes.Buffer
+	exported, exportErr := source.Export(&archive)
+	target := New(filepath.Join(t.TempDir(), "imported"))
+	imported, importErr := target.Import(&archive)
+
+	// THEN
+	if exportErr != nil || importErr != nil {
+		t.Fatalf("Unexpected errors: %v, %v", exportErr, importErr)
+	}
+	if exported != 2 || imported != 2 {
+		t.Errorf("Expected 2 entries exported and imported, got %d and %d", exported, imported)
+	}
+	items, _ := target.Items()
+	if len(items) != 2 || !items[0].LastUsed.Equal(lastUsed) {
+		t.Errorf("Expected the last use to be preserved, got %+v", items)
+	}
+	resp, ok := target.Lookup("https://picsum.photos/seed/x/300")
+	if !ok {
+		t.Fatal("Expected imported entry to be served")
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "seeded" {
+		t.Errorf("Unexpected imported body %q", body)
+	}
+}
+
+// tarball creates a gzip-compressed tarball of the given files
+func tarball(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	return &buf
+}
+
+func TestImport_RejectsForeignFiles(t *testing.T) {
+	mismatched := Key("https://picsum.photos/id/1/300") + ".json"
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"path traversal", map[string]string{"../evil.body": "x"}},
+		{"unexpected name", map[string]string{"notes.txt": "x"}},
+		{"key does not match URL", map[string]string{mismatched: `{"url":"https://picsum.photos/id/2/300"}`}},
+		{"invalid entry", map[string]string{mismatched: "not json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := New(dir).Import(tarball(t, tt.files)); err == nil {
+				t.Error("Expected import to fail")
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil.body")); err == nil {
+				t.Error("Expected no file outside the cache directory")
+			}
+		})
+	}
+}
+
+func TestImport_NotGzip(t *testing.T) {
+	if _, err := New(t.TempDir()).Import(strings.NewReader("plain text")); err == nil {
+		t.Error("Expected import of a non-gzip file to fail")
+	}
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats supported by WriteItems and WriteStats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// sizeUnits are the binary multiples accepted by ParseSize and printed by FormatSize
+var sizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+// ValidateFormat validates the output format name
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q, must be one of %s, %s", format, FormatTable, FormatJSON)
+}
+
+// FormatSize prints a byte count with a binary unit, e.g. 1.5 MiB
+func FormatSize(size int64) string {
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(sizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, sizeUnits[unit])
+}
+
+/*
+ParseSize parses a byte count such as 500000, 200K, 1.5MiB or 2G,
+where K, M, G and T are binary multiples with or without the iB suffix
+*/
+func ParseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+	for i, unit := range []string{"K", "M", "G", "T"} {
+		if rest, ok := strings.CutSuffix(s, unit); ok {
+			s = rest
+			multiplier = int64(1) << (10 * (i + 1))
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	size := number * float64(multiplier)
+	// Also rejects NaN, infinities and sizes beyond int64
+	if err != nil || !(size >= 0) || size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+	return int64(size), nil
+}
+
+// WriteItems prints the cache entries to w in the given format
+func WriteItems(w io.Writer, items []Item, format string) error {
+	switch format {
+	case FormatJSON:
+		if items == nil {
+			items = []Item{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	case FormatTable:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "LAST USED\tSIZE\tURL")
+		for _, item := range items {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", item.LastUsed.Format(time.DateTime), FormatSize(item.Size), item.URL)
+		}
+		return writer.Flush()
+	}
+	return ValidateFormat(format)
+}
+
+// WriteStats prints the cache summary to w in the given format
+func WriteStats(w io.Writer, stats *Stats, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	case FormatTable:
+		writer := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+		_, _ = fmt.Fprintf(writer, "Directory:\t%s\n", stats.Dir)
+		_, _ = fmt.Fprintf(writer, "Entries:\t%d\n", stats.Entries)
+		_, _ = fmt.Fprintf(writer, "Size:\t%s\n", FormatSize(stats.Size))
+		if stats.Entries > 0 {
+			_, _ = fmt.Fprintf(writer, "Oldest use:\t%s\n", stats.OldestUsed.Format(time.DateTime))
+			_, _ = fmt.Fprintf(writer, "Newest use:\t%s\n", stats.NewestUsed.Format(time.DateTime))
+		}
+		return writer.Flush()
+	}
+	return ValidateFormat(format)
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536 * 1024, "1.5 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.size); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"500000", 500000, false},
+		{"100B", 100, false},
+		{"200K", 200 << 10, false},
+		{"1.5MiB", 3 << 19, false},
+		{"2g", 2 << 30, false},
+		{"1 TB", 1 << 40, false},
+		{"", 0, true},
+		{"lots", 0, true},
+		{"-5M", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"+InfG", 0, true},
+		{"1e30T", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteItems_Formats(t *testing.T) {
+	lastUsed := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+	items := []Item{{Entry: Entry{URL: "https://picsum.photos/id/237/300", Size: 2048}, Key: "abc", LastUsed: lastUsed}}
+
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{FormatTable, []string{"LAST USED", "2024-05-06 07:08:09  2.0 KiB  https://picsum.photos/id/237/300"}},
+		{FormatJSON, []string{`"url": "https://picsum.photos/id/237/300"`, `"size": 2048`, `"key": "abc"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteItems(&buf, items, tt.format); err != nil {
+				t.Fatalf("WriteItems failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	var buf bytes.Buffer
+	used := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+	stats := &Stats{Dir: "/tmp/picsum", Entries: 3, Size: 4096, OldestUsed: used, NewestUsed: used}
+	if err := WriteStats(&buf, stats, FormatTable); err != nil {
+		t.Fatalf("WriteStats failed: %v", err)
+	}
+	for _, want := range []string{"Directory:  /tmp/picsum\n", "Entries:    3\n", "Size:       4.0 KiB\n", "Oldest use: 2024-05-06 07:08:09\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteItems(&buf, nil, "csv"); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+	if err := WriteStats(&buf, &Stats{}, "csv"); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+}
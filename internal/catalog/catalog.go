@@ -0,0 +1,117 @@
+/*
+Package catalog to list the images available on picsum.photos
+*/
+package catalog
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// Output formats supported by Write
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// MaxLimit is the largest page size accepted by the picsum.photos list API
+const MaxLimit = 100
+
+// ValidatePaging validates the page number and page size
+func ValidatePaging(page, limit int) error {
+	if page < 1 {
+		return fmt.Errorf("page must be at least 1, got %d", page)
+	}
+	if limit < 1 || limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d, got %d", MaxLimit, limit)
+	}
+	return nil
+}
+
+// ValidateFormat validates the output format name
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV:
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q, must be one of %s, %s, %s", format, FormatTable, FormatJSON, FormatCSV)
+}
+
+/*
+ListWithClient fetches a single page of the catalog served at baseURL using
+the provided HTTP client
+*/
+func ListWithClient(ctx context.Context, client httpclient.Getter, baseURL string, page, limit int) ([]imageinfo.Info, error) {
+	resp, err := client.Get(ctx, urlbuilder.BuildListURL(baseURL, page, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status: %s", resp.Status)
+	}
+
+	var images []imageinfo.Info
+	if err := json.NewDecoder(resp.Body).Decode(&images); err != nil {
+		return nil, fmt.Errorf("failed to decode image list: %v", err)
+	}
+	return images, nil
+}
+
+// Write prints the images to w in the given format
+func Write(w io.Writer, images []imageinfo.Info, format string) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, images)
+	case FormatCSV:
+		return writeCSV(w, images)
+	case FormatTable:
+		return writeTable(w, images)
+	}
+	return ValidateFormat(format)
+}
+
+func writeJSON(w io.Writer, images []imageinfo.Info) error {
+	if images == nil {
+		images = []imageinfo.Info{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(images)
+}
+
+func writeCSV(w io.Writer, images []imageinfo.Info) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "author", "width", "height", "url"}); err != nil {
+		return err
+	}
+	for _, image := range images {
+		record := []string{image.ID, image.Author, strconv.Itoa(image.Width), strconv.Itoa(image.Height), image.URL}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeTable(w io.Writer, images []imageinfo.Info) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "ID\tAUTHOR\tWIDTH\tHEIGHT\tURL")
+	for _, image := range images {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%d\t%d\t%s\n", image.ID, image.Author, image.Width, image.Height, image.URL)
+	}
+	return writer.Flush()
+}
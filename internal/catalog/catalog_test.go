@@ -0,0 +1,180 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/imageinfo"
+)
+
+// serverClient is an httpclient.Getter that sends every request to a test server
+type serverClient struct {
+	server *httptest.Server
+}
+
+func (c *serverClient) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server.URL+u.RequestURI(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// newCatalogServer serves a fake catalog of total images through /v2/list
+func newCatalogServer(t *testing.T, total int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		images := []imageinfo.Info{}
+		for i := (page - 1) * limit; i < page*limit && i < total; i++ {
+			images = append(images, imageinfo.Info{
+				ID:     strconv.Itoa(i),
+				Author: fmt.Sprintf("Author %d", i),
+				Width:  5000,
+				Height: 3333,
+				URL:    fmt.Sprintf("https://unsplash.com/photos/%d", i),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(images)
+	}))
+}
+
+func TestListWithClient_Success(t *testing.T) {
+	// GIVEN
+	server := newCatalogServer(t, 10)
+	defer server.Close()
+
+	// WHEN
+	images, err := ListWithClient(context.Background(), &serverClient{server}, server.URL, 2, 3)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ListWithClient failed: %v", err)
+	}
+	if len(images) != 3 {
+		t.Fatalf("Expected 3 images, got %d", len(images))
+	}
+	if images[0].ID != "3" || images[0].Author != "Author 3" || images[0].Width != 5000 {
+		t.Errorf("Unexpected first image: %+v", images[0])
+	}
+}
+
+func TestListWithClient_NonOKStatus(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// WHEN
+	_, err := ListWithClient(context.Background(), &serverClient{server}, server.URL, 1, 10)
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "server returned status") {
+		t.Errorf("Expected server status error, got %v", err)
+	}
+}
+
+func TestListWithClient_InvalidJSON(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>not json</html>"))
+	}))
+	defer server.Close()
+
+	// WHEN
+	_, err := ListWithClient(context.Background(), &serverClient{server}, server.URL, 1, 10)
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "failed to decode image list") {
+		t.Errorf("Expected decode error, got %v", err)
+	}
+}
+
+func TestValidatePaging(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    int
+		limit   int
+		wantErr bool
+	}{
+		{"valid", 1, 30, false},
+		{"max limit", 3, MaxLimit, false},
+		{"page zero", 0, 30, true},
+		{"limit zero", 1, 0, true},
+		{"limit too high", 1, MaxLimit + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePaging(tt.page, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePaging() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrite_Formats(t *testing.T) {
+	images := []imageinfo.Info{
+		{ID: "0", Author: "Alejandro Escamilla", Width: 5000, Height: 3333, URL: "https://unsplash.com/photos/yC-Yzbqy7PY"},
+		{ID: "1", Author: "Doe, Jane", Width: 640, Height: 480, URL: "https://unsplash.com/photos/x"},
+	}
+
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{FormatTable, []string{"ID  AUTHOR", "0   Alejandro Escamilla  5000   3333"}},
+		{FormatCSV, []string{"id,author,width,height,url\n", "1,\"Doe, Jane\",640,480,https://unsplash.com/photos/x\n"}},
+		{FormatJSON, []string{`"author": "Alejandro Escamilla"`, `"width": 640`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, images, tt.format); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWrite_EmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, nil, FormatJSON); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected empty JSON array, got %q", buf.String())
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, nil, "xml"); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Package checksum to compute SHA-256 digests of files and keep them in coreutils sha256sum files
+*/
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrMismatch is returned by Check when a file does not have the expected digest
+var ErrMismatch = errors.New("checksum mismatch")
+
+// sumLine matches a line of sha256sum output in text or binary mode
+var sumLine = regexp.MustCompile(`^(\\?)([0-9a-fA-F]{64}) [ *](.+)$`)
+
+// appendMu serialises appends when images are saved concurrently
+var appendMu sync.Mutex
+
+// Entry is a line of a checksum file
+type Entry struct {
+	SHA256 string
+	Path   string
+}
+
+// File returns the hex SHA-256 digest of the file at path
+func File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+/*
+Check reports whether the file at path has the hex digest want. It returns
+ErrMismatch when the content differs and the error of reading it otherwise.
+*/
+func Check(path, want string) error {
+	got, err := File(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, want) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+/*
+Read parses a checksum file written by sha256sum or Append. Blank lines are
+skipped and any other line not in the sha256sum format is an error.
+*/
+func Read(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checksum file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		match := sumLine.FindStringSubmatch(text)
+		if match == nil {
+			return nil, fmt.Errorf("%s line %d: improperly formatted SHA256 checksum line", path, line)
+		}
+		name := match[3]
+		if match[1] != "" {
+			name = unescape(name)
+		}
+		entries = append(entries, Entry{SHA256: strings.ToLower(match[2]), Path: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksum file: %v", err)
+	}
+	return entries, nil
+}
+
+/*
+Append adds the entry to the checksum file at path in the sha256sum text
+format, creating the file if needed
+*/
+func Append(path string, entry Entry) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open checksum file: %v", err)
+	}
+	_, err = file.WriteString(Format(entry))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checksum file: %v", err)
+	}
+	return nil
+}
+
+/*
+Format returns the sha256sum line of entry. Like sha256sum, names with a
+backslash or newline are escaped and the line is marked with a leading backslash.
+*/
+func Format(entry Entry) string {
+	prefix := ""
+	name := entry.Path
+	if strings.ContainsAny(name, "\\\n") {
+		prefix = "\\"
+		name = strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(name)
+	}
+	return fmt.Sprintf("%s%s  %s\n", prefix, entry.SHA256, name)
+}
+
+// unescape reverses the escaping of Format
+func unescape(name string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range name {
+		switch {
+		case escaped && r == 'n':
+			b.WriteRune('\n')
+			escaped = false
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
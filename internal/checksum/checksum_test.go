@@ -0,0 +1,175 @@
+package checksum
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// imageSum is the SHA-256 of "image bytes"
+const imageSum = "de7030234493a8bea844dbe1d8676e68a2c1a4b014c721f0425a22b6df66faec"
+
+// writeFile writes content to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFile(t *testing.T) {
+	// GIVEN
+	path := writeFile(t, t.TempDir(), "a.jpg", "image bytes")
+
+	// WHEN
+	got, err := File(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("File() unexpected error: %v", err)
+	}
+	if got != imageSum {
+		t.Errorf("File() = %s, want %s", got, imageSum)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := writeFile(t, dir, "a.jpg", "image bytes")
+
+	// THEN
+	if err := Check(path, imageSum); err != nil {
+		t.Errorf("Check() with the right digest = %v, want nil", err)
+	}
+	if err := Check(path, strings.ToUpper(imageSum)); err != nil {
+		t.Errorf("Check() with an upper case digest = %v, want nil", err)
+	}
+	if err := Check(path, strings.Repeat("0", 64)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Check() with another digest = %v, want ErrMismatch", err)
+	}
+	if err := Check(filepath.Join(dir, "missing.jpg"), imageSum); err == nil || errors.Is(err, ErrMismatch) {
+		t.Errorf("Check() of a missing file = %v, want a read error", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"plain", "hero.jpg", imageSum + "  hero.jpg\n"},
+		{"space", "my hero.jpg", imageSum + "  my hero.jpg\n"},
+		{"backslash", `a\b.jpg`, `\` + imageSum + `  a\\b.jpg` + "\n"},
+		{"newline", "a\nb.jpg", `\` + imageSum + `  a\nb.jpg` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(Entry{SHA256: imageSum, Path: tt.path}); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendAndRead(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	entries := []Entry{
+		{SHA256: imageSum, Path: "hero.jpg"},
+		{SHA256: imageSum, Path: "dir/my hero.jpg"},
+		{SHA256: imageSum, Path: "odd\\name\n.jpg"},
+	}
+
+	// WHEN
+	for _, entry := range entries {
+		if err := Append(path, entry); err != nil {
+			t.Fatalf("Append() unexpected error: %v", err)
+		}
+	}
+	got, err := Read(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("Read() = %q, want %q", got, entries)
+	}
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+
+	// WHEN
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = Append(path, Entry{SHA256: imageSum, Path: "hero.jpg"})
+		}()
+	}
+	wg.Wait()
+
+	// THEN
+	got, err := Read(path)
+	if err != nil || len(got) != 20 {
+		t.Errorf("Read() = %d entries, err %v, want 20 entries", len(got), err)
+	}
+}
+
+func TestRead_CoreutilsFormats(t *testing.T) {
+	// GIVEN
+	upper := strings.ToUpper(imageSum)
+	path := writeFile(t, t.TempDir(), "SHA256SUMS",
+		imageSum+"  text.jpg\n"+
+			"\n"+
+			imageSum+" *binary.jpg\r\n"+
+			upper+"  upper.jpg\n")
+
+	// WHEN
+	got, err := Read(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	want := []Entry{
+		{SHA256: imageSum, Path: "text.jpg"},
+		{SHA256: imageSum, Path: "binary.jpg"},
+		{SHA256: imageSum, Path: "upper.jpg"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	// WHEN
+	_, err := Read(writeFile(t, dir, "bad", imageSum+"  ok.jpg\nnot a checksum\n"))
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "line 2: improperly formatted SHA256 checksum line") {
+		t.Errorf("Read() error = %v, want improperly formatted line 2", err)
+	}
+
+	// WHEN
+	_, err = Read(filepath.Join(dir, "missing"))
+
+	// THEN
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to open checksum file:") {
+		t.Errorf("Read() error = %v, want open error", err)
+	}
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/cache"
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/output"
+	"github.com/urfave/cli/v3"
+)
+
+// cacheCommand creates the subcommand that manages the response cache
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "inspect, prune and move the cache of --id and --seed responses",
+		Description: "Manage the cache in --cache-dir, or picsum in the user cache directory:\n" +
+			"  picsum cache ls\n" +
+			"  picsum cache prune --max-age 720h --max-size 500M\n" +
+			"  picsum cache export cache.tar.gz\n" +
+			"  picsum cache import cache.tar.gz",
+		Commands: []*cli.Command{
+			{
+				Name:   "ls",
+				Usage:  "list cached responses with their source URL, size and last use",
+				Flags:  []cli.Flag{cacheFormatFlag()},
+				Action: cacheLsAction,
+			},
+			{
+				Name:   "stats",
+				Usage:  "show the number of cached responses and their total size",
+				Flags:  []cli.Flag{cacheFormatFlag()},
+				Action: cacheStatsAction,
+			},
+			{
+				Name:   "prune",
+				Usage:  "remove responses by age or, least recently used first, down to a size budget",
+				Flags:  pruneFlags(),
+				Action: cachePruneAction,
+			},
+			{
+				Name:   "clear",
+				Usage:  "remove every cached response",
+				Action: cacheClearAction,
+			},
+			{
+				Name:      "export",
+				Usage:     "write the cache to a .tar.gz file, or - for standard output; asks before replacing a file unless --force",
+				ArgsUsage: "<file>",
+				Action:    cacheExportAction,
+			},
+			{
+				Name:      "import",
+				Usage:     "load a .tar.gz file written by export, or - for standard input",
+				ArgsUsage: "<file>",
+				Action:    cacheImportAction,
+			},
+		},
+	}
+}
+
+func cacheFormatFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "output-format",
+		Usage: "output format: table or json",
+		Value: cache.FormatTable,
+	}
+}
+
+func pruneFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "max-age",
+			Usage: "remove responses not used within this duration, e.g. 720h",
+		},
+		&cli.StringFlag{
+			Name:  "max-size",
+			Usage: "remove least recently used responses until the cache fits, e.g. 500M or 2GiB",
+		},
+	}
+}
+
+// openCache opens the cache selected by --cache-dir
+func openCache(c *cli.Command) (*cache.Cache, error) {
+	return cache.Open(c.String("cache-dir"))
+}
+
+// fileArgument returns the single file argument of export and import
+func fileArgument(c *cli.Command) (string, error) {
+	if c.Args().Len() != 1 {
+		return "", fmt.Errorf("expected exactly one file argument, got %d", c.Args().Len())
+	}
+	return c.Args().First(), nil
+}
+
+func cacheLsAction(_ context.Context, c *cli.Command) error {
+	format := c.String("output-format")
+	if err := cache.ValidateFormat(format); err != nil {
+		return err
+	}
+	store, err := openCache(c)
+	if err != nil {
+		return err
+	}
+	items, err := store.Items()
+	if err != nil {
+		return err
+	}
+	return cache.WriteItems(os.Stdout, items, format)
+}
+
+func cacheStatsAction(_ context.Context, c *cli.Command) error {
+	format := c.String("output-format")
+	if err := cache.ValidateFormat(format); err != nil {
+		return err
+	}
+	store, err := openCache(c)
+	if err != nil {
+		return err
+	}
+	stats, err := store.Stats()
+	if err != nil {
+		return err
+	}
+	return cache.WriteStats(os.Stdout, stats, format)
+}
+
+func cachePruneAction(_ context.Context, c *cli.Command) error {
+	maxAge := c.Duration("max-age")
+	if maxAge < 0 {
+		return fmt.Errorf("max age must not be negative")
+	}
+	var maxSize int64
+	if value := c.String("max-size"); value != "" {
+		var err error
+		if maxSize, err = cache.ParseSize(value); err != nil {
+			return err
+		}
+		if maxSize == 0 {
+			return fmt.Errorf("max size must be greater than 0, use 'picsum cache clear' to empty the cache")
+		}
+	}
+	if maxAge == 0 && maxSize == 0 {
+		return fmt.Errorf("one of --max-age or --max-size is required")
+	}
+
+	store, err := openCache(c)
+	if err != nil {
+		return err
+	}
+	removed, err := store.Prune(maxAge, maxSize, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !c.Bool("quiet") {
+		var freed int64
+		for _, item := range removed {
+			freed += item.Size
+		}
+		console.Stdoutln("Removed %d entries, freed %s", len(removed), cache.FormatSize(freed))
+	}
+	return nil
+}
+
+func cacheClearAction(_ context.Context, c *cli.Command) error {
+	store, err := openCache(c)
+	if err != nil {
+		return err
+	}
+	count, err := store.Clear()
+	if err != nil {
+		return err
+	}
+	if !c.Bool("quiet") {
+		console.Stdoutln("Removed %d entries from %s", count, store.Dir())
+	}
+	return nil
+}
+
+func cacheExportAction(ctx context.Context, c *cli.Command) error {
+	filename, err := fileArgument(c)
+	if err != nil {
+		return err
+	}
+	store, err := openCache(c)
+	if err != nil {
+		return err
+	}
+
+	if filename == output.StdoutPath {
+		_, err := store.Export(os.Stdout)
+		return err
+	}
+
+	var count int
+	err = output.WriteFile(ctx, filename, c.Bool("force"), func(w io.Writer) error {
+		count, err = store.Export(w)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !c.Bool("quiet") {
+		console.Stdoutln("Exported %d entries to %s", count, filename)
+	}
+	return nil
+}
+
+func cacheImportAction(_ context.Context, c *cli.Command) error {
+	filename, err := fileArgument(c)
+	if err != nil {
+		return err
+	}
+	store, err := openCache(c)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		r = file
+	}
+
+	count, err := store.Import(r)
+	if err != nil {
+		return err
+	}
+	if !c.Bool("quiet") {
+		console.Stdoutln("Imported %d entries into %s", count, store.Dir())
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheCommand(t *testing.T) {
+	cmd := cacheCommand()
+
+	if cmd.Name != "cache" {
+		t.Errorf("cacheCommand() Name = %v, want %v", cmd.Name, "cache")
+	}
+	for _, name := range []string{"ls", "stats", "prune", "clear", "export", "import"} {
+		sub := cmd.Command(name)
+		if sub == nil {
+			t.Errorf("cacheCommand() should have a %s subcommand", name)
+			continue
+		}
+		if sub.Action == nil {
+			t.Errorf("cache %s Action is nil", name)
+		}
+	}
+}
+
+func TestBuildCommand_HasCacheSubcommand(t *testing.T) {
+	if BuildCommand().Command("cache") == nil {
+		t.Error("BuildCommand() should have a cache subcommand")
+	}
+}
+
+func TestCacheAction_Validation(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{
+			name:   "prune without limits",
+			args:   []string{"picsum", "--cache-dir", dir, "cache", "prune"},
+			errMsg: "one of --max-age or --max-size is required",
+		},
+		{
+			name:   "prune with invalid size",
+			args:   []string{"picsum", "--cache-dir", dir, "cache", "prune", "--max-size", "lots"},
+			errMsg: "invalid size",
+		},
+		{
+			name:   "ls with unsupported format",
+			args:   []string{"picsum", "--cache-dir", dir, "cache", "ls", "--output-format", "csv"},
+			errMsg: "unsupported format",
+		},
+		{
+			name:   "export without file",
+			args:   []string{"picsum", "--cache-dir", dir, "cache", "export"},
+			errMsg: "expected exactly one file argument",
+		},
+		{
+			name:   "import missing file",
+			args:   []string{"picsum", "--cache-dir", dir, "cache", "import", filepath.Join(dir, "missing.tar.gz")},
+			errMsg: "failed to open file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildCommand().Run(context.Background(), tt.args)
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestCacheAction_ExportImportEmptyCache(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "cache.tar.gz")
+
+	// WHEN
+	exportErr := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "--cache-dir", filepath.Join(dir, "a"), "cache", "export", archive})
+	importErr := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "--cache-dir", filepath.Join(dir, "b"), "cache", "import", archive})
+
+	// THEN
+	if exportErr != nil || importErr != nil {
+		t.Fatalf("Unexpected errors: %v, %v", exportErr, importErr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b")); err != nil {
+		t.Errorf("Expected import to create the cache directory: %v", err)
+	}
+}
+
+func TestCacheAction_ExportKeepsExistingFileWhenDeclined(t *testing.T) {
+	// GIVEN an existing file and a user who answers no
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "cache.tar.gz")
+	if err := os.WriteFile(archive, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	r, w, _ := os.Pipe()
+	os.Stdin = r
+	_, _ = w.Write([]byte("n\n"))
+	_ = w.Close()
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "--cache-dir", filepath.Join(dir, "a"), "cache", "export", archive})
+
+	// THEN
+	if err == nil {
+		t.Fatal("Expected an error when the overwrite is declined")
+	}
+	if data, _ := os.ReadFile(archive); string(data) != "keep me" {
+		t.Errorf("Expected existing file to be untouched, got %q", data)
+	}
+}
+
+func TestCacheAction_ExportForceReplacesExistingFile(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "cache.tar.gz")
+	if err := os.WriteFile(archive, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "--cache-dir", filepath.Join(dir, "a"), "cache", "export", "--force", archive})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(archive); string(data) == "old" {
+		t.Error("Expected the archive to be replaced")
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("Expected temporary files to be removed, found %v", matches)
+	}
+}
@@ -5,8 +5,13 @@ package cli
 
 import (
 	"context"
+	"time"
 
 	"github.com/siakhooi/picsum/internal/arguments"
+	"github.com/siakhooi/picsum/internal/batch"
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/provider"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
 	"github.com/siakhooi/picsum/internal/versioninfo"
 	"github.com/urfave/cli/v3"
 )
@@ -21,9 +26,20 @@ func BuildCommand() *cli.Command {
 		Description: "Fetch a photo from https://picsum.photos.\n" +
 			"Requires 1 or 2 positional arguments:\n" +
 			"  picsum <size>             square image of <size> pixels\n" +
-			"  picsum <width> <height>   image of <width> x <height> pixels",
+			"  picsum <width> <height>   image of <width> x <height> pixels\n" +
+			"Use --count to download several distinct random images in parallel.\n" +
+			"Use --input to download a list of images, one command line per line.\n" +
+			"Use 'picsum list' to discover image IDs for --id.",
 		Flags:  buildFlags(),
 		Action: runAction,
+		Commands: []*cli.Command{
+			listCommand(),
+			infoCommand(),
+			cacheCommand(),
+			serveCommand(),
+			syncCommand(),
+			verifyCommand(),
+		},
 	}
 }
 
@@ -62,13 +78,133 @@ func buildFlags() []cli.Flag {
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
-			Usage:   "output file path",
+			Usage:   "output file path or - for standard output, may contain --name-template placeholders",
+		},
+		&cli.StringFlag{
+			Name:    "name-template",
+			Aliases: []string{"t"},
+			Usage: "output filename template, directories are created as needed; placeholders: " +
+				"{id} {seed} {width} {height} {gray} {blur} {author} {date} {n} {ext}",
+			Sources: cli.EnvVars("PICSUM_NAME_TEMPLATE"),
 		},
 		&cli.BoolFlag{
 			Name:    "force",
 			Aliases: []string{"f"},
 			Usage:   "overwrite existing file without prompting",
 		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "image format to request and save: jpg or webp (default: the server default, saved as .jpg)",
+		},
+		&cli.StringFlag{
+			Name:  "convert",
+			Usage: "re-encode the image as png, gif or jpeg before saving (default: the format of the --output extension)",
+		},
+		&cli.IntFlag{
+			Name:  "quality",
+			Usage: "JPEG quality 1-100 of the re-encoded image, implies --convert jpeg, 0 for the encoder default of 75",
+		},
+		&cli.StringFlag{
+			Name:  "resize",
+			Usage: "resize the image to WxH before saving, up to 10000x10000",
+		},
+		&cli.StringFlag{
+			Name:  "crop",
+			Usage: "crop the WxH region at offset X,Y of the downloaded image, as WxH+X+Y, before --resize",
+		},
+		&cli.StringFlag{
+			Name:  "fit",
+			Usage: "keep the aspect ratio in --resize: cover to fill WxH and crop the overflow, contain to fit inside WxH and pad (default: stretch to WxH)",
+		},
+		&cli.StringFlag{
+			Name:  "pad-color",
+			Usage: "color of the padding of --fit contain, as #rgb or #rrggbb (default: #ffffff)",
+		},
+		&cli.StringFlag{
+			Name:  "filter",
+			Usage: "comma-separated effects applied in order after download: sepia, invert, tint=#rrggbb, brightness=F, contrast=F (F of 1 keeps the image)",
+		},
+		&cli.BoolFlag{
+			Name:  "no-validate",
+			Usage: "save the download without checking that it is an image of the requested size",
+		},
+		&cli.IntFlag{
+			Name:    "count",
+			Aliases: []string{"n"},
+			Usage:   "number of distinct random images to download",
+			Value:   1,
+		},
+		&cli.IntFlag{
+			Name:    "concurrency",
+			Aliases: []string{"c"},
+			Usage:   "number of parallel downloads when --count is greater than 1 or of parallel --input jobs",
+			Value:   batch.DefaultConcurrency,
+		},
+		&cli.BoolFlag{
+			Name:    "metadata",
+			Aliases: []string{"m"},
+			Usage:   "write a .json sidecar with the source URL, image ID, author and SHA-256",
+		},
+		&cli.BoolFlag{
+			Name:  "print-id",
+			Usage: "print the picsum.photos image ID that was served, to reproduce it with --id",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "maximum time for each HTTP request including the download, 0 for no limit",
+			Value: httpclient.DefaultTimeout,
+		},
+		&cli.DurationFlag{
+			Name:  "connect-timeout",
+			Usage: "maximum time to establish a connection, 0 for no limit",
+			Value: httpclient.DefaultConnectTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "retries",
+			Usage: "number of times to retry a request after a network error, 429 or 5xx response",
+			Value: httpclient.DefaultRetries,
+		},
+		&cli.DurationFlag{
+			Name:  "retry-max-wait",
+			Usage: "longest wait between retries, also the longest Retry-After honoured",
+			Value: httpclient.DefaultRetryMaxWait,
+		},
+		&cli.StringFlag{
+			Name:    "base-url",
+			Usage:   "root URL of a Lorem Picsum mirror, may include a path prefix",
+			Value:   urlbuilder.DefaultBaseURL,
+			Sources: cli.EnvVars("PICSUM_BASE_URL"),
+		},
+		&cli.StringFlag{
+			Name:  "cache-dir",
+			Usage: "directory of the cache of --id and --seed responses (default: picsum in the user cache directory)",
+		},
+		&cli.BoolFlag{
+			Name:  "offline",
+			Usage: "serve images from the cache only, fail instead of fetching",
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "neither read nor write the cache",
+		},
+		&cli.StringFlag{
+			Name:  "provider",
+			Usage: "image source: picsum, local to generate images without a network, or a provider of --provider-config",
+			Value: provider.Picsum,
+		},
+		&cli.StringFlag{
+			Name:    "provider-config",
+			Usage:   "JSON file defining templated URL providers (default: picsum/providers.json in the user config directory)",
+			Sources: cli.EnvVars("PICSUM_PROVIDER_CONFIG"),
+		},
+		&cli.StringFlag{
+			Name:  "checksum-file",
+			Usage: "append the SHA-256 of every saved image to this file in sha256sum format, read by verify",
+		},
+		&cli.StringFlag{
+			Name:  "input",
+			Usage: "file of download jobs, one command line such as '200 300 --id 237 -o hero.jpg' per line, or - for standard input",
+		},
 		&cli.BoolFlag{
 			Name:  "build",
 			Usage: "print build info and exit",
@@ -76,32 +212,106 @@ func buildFlags() []cli.Flag {
 	}
 }
 
-func runAction(_ context.Context, c *cli.Command) error {
+func runAction(ctx context.Context, c *cli.Command) error {
 	if c.Bool("build") {
 		versioninfo.PrintBuildInfo()
 		return nil
 	}
 
+	if c.String("input") != "" {
+		return runInput(ctx, c)
+	}
+
 	args := c.Args().Slice()
 
 	if err := arguments.ValidateArguments(args); err != nil {
 		return err
 	}
 
-	opts := &arguments.Options{
-		ImageID:    c.String("id"),
-		Seed:       c.String("seed"),
-		Grayscale:  c.Bool("gray"),
-		Blur:       c.Bool("blur"),
-		BlurLevel:  c.Int("blurlevel"),
-		Quiet:      c.Bool("quiet"),
-		OutputPath: c.String("output"),
-		Force:      c.Bool("force"),
-	}
-
+	opts := newOptions(c)
 	if err := arguments.ValidateOptions(opts); err != nil {
 		return err
 	}
 
-	return arguments.ProcessImage(args, opts)
+	return arguments.ProcessImage(ctx, args, opts)
+}
+
+// flagValues reads flag values by name, implemented by *cli.Command
+type flagValues interface {
+	String(name string) string
+	Bool(name string) bool
+	Int(name string) int
+	Duration(name string) time.Duration
+}
+
+// newOptions collects the download options from the flags
+func newOptions(f flagValues) *arguments.Options {
+	return &arguments.Options{
+		ImageID:        f.String("id"),
+		Seed:           f.String("seed"),
+		Grayscale:      f.Bool("gray"),
+		Blur:           f.Bool("blur"),
+		BlurLevel:      f.Int("blurlevel"),
+		Quiet:          f.Bool("quiet"),
+		OutputPath:     f.String("output"),
+		Force:          f.Bool("force"),
+		Count:          f.Int("count"),
+		Concurrency:    f.Int("concurrency"),
+		Metadata:       f.Bool("metadata"),
+		PrintID:        f.Bool("print-id"),
+		NameTemplate:   f.String("name-template"),
+		Timeout:        f.Duration("timeout"),
+		ConnectTimeout: f.Duration("connect-timeout"),
+		Retries:        f.Int("retries"),
+		RetryMaxWait:   f.Duration("retry-max-wait"),
+		CacheDir:       f.String("cache-dir"),
+		Offline:        f.Bool("offline"),
+		NoCache:        f.Bool("no-cache"),
+		BaseURL:        f.String("base-url"),
+		Provider:       f.String("provider"),
+		ProviderConfig: f.String("provider-config"),
+		ChecksumFile:   f.String("checksum-file"),
+		Format:         f.String("format"),
+		Convert:        f.String("convert"),
+		Quality:        f.Int("quality"),
+		NoValidate:     f.Bool("no-validate"),
+		Resize:         f.String("resize"),
+		Crop:           f.String("crop"),
+		Fit:            f.String("fit"),
+		PadColor:       f.String("pad-color"),
+		Filter:         f.String("filter"),
+	}
+}
+
+// sourceOptions returns the flags that select where images come from, shared by the subcommands
+func sourceOptions(c *cli.Command) *arguments.Options {
+	return &arguments.Options{
+		Timeout:        c.Duration("timeout"),
+		ConnectTimeout: c.Duration("connect-timeout"),
+		Retries:        c.Int("retries"),
+		RetryMaxWait:   c.Duration("retry-max-wait"),
+		CacheDir:       c.String("cache-dir"),
+		Offline:        c.Bool("offline"),
+		NoCache:        c.Bool("no-cache"),
+		BaseURL:        c.String("base-url"),
+		Provider:       c.String("provider"),
+		ProviderConfig: c.String("provider-config"),
+	}
+}
+
+// newSource creates the provider and the HTTP client configured by the provider, timeout, retry and cache flags
+func newSource(c *cli.Command) (provider.Provider, httpclient.Getter, error) {
+	opts := sourceOptions(c)
+	if err := arguments.ValidateOptions(opts); err != nil {
+		return nil, nil, err
+	}
+	p, err := arguments.NewProvider(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := arguments.NewHTTPClient(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, client, nil
 }
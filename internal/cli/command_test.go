@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/siakhooi/picsum/internal/provider"
 	"github.com/urfave/cli/v3"
 )
 
@@ -32,16 +33,16 @@ func TestBuildCommand(t *testing.T) {
 		t.Fatal("BuildCommand() Flags is nil")
 	}
 
-	if len(cmd.Flags) != 9 {
-		t.Errorf("BuildCommand() Flags length = %v, want %v", len(cmd.Flags), 9)
+	if len(cmd.Flags) != 35 {
+		t.Errorf("BuildCommand() Flags length = %v, want %v", len(cmd.Flags), 35)
 	}
 }
 
 func TestBuildFlags(t *testing.T) {
 	flags := buildFlags()
 
-	if len(flags) != 9 {
-		t.Errorf("buildFlags() returned %d flags, want 9", len(flags))
+	if len(flags) != 35 {
+		t.Errorf("buildFlags() returned %d flags, want 35", len(flags))
 	}
 
 	tests := []struct {
@@ -98,7 +99,15 @@ func TestBuildFlags(t *testing.T) {
 			flagName:    "output",
 			flagType:    "*cli.StringFlag",
 			aliases:     []string{"o"},
-			description: "output file path",
+			description: "output file path or - for standard output, may contain --name-template placeholders",
+		},
+		{
+			name:     "name-template flag",
+			flagName: "name-template",
+			flagType: "*cli.StringFlag",
+			aliases:  []string{"t"},
+			description: "output filename template, directories are created as needed; placeholders: " +
+				"{id} {seed} {width} {height} {gray} {blur} {author} {date} {n} {ext}",
 		},
 		{
 			name:        "force flag",
@@ -107,6 +116,181 @@ func TestBuildFlags(t *testing.T) {
 			aliases:     []string{"f"},
 			description: "overwrite existing file without prompting",
 		},
+		{
+			name:        "count flag",
+			flagName:    "count",
+			flagType:    "*cli.IntFlag",
+			aliases:     []string{"n"},
+			description: "number of distinct random images to download",
+		},
+		{
+			name:        "concurrency flag",
+			flagName:    "concurrency",
+			flagType:    "*cli.IntFlag",
+			aliases:     []string{"c"},
+			description: "number of parallel downloads when --count is greater than 1 or of parallel --input jobs",
+		},
+		{
+			name:        "metadata flag",
+			flagName:    "metadata",
+			flagType:    "*cli.BoolFlag",
+			aliases:     []string{"m"},
+			description: "write a .json sidecar with the source URL, image ID, author and SHA-256",
+		},
+		{
+			name:        "print-id flag",
+			flagName:    "print-id",
+			flagType:    "*cli.BoolFlag",
+			aliases:     []string{},
+			description: "print the picsum.photos image ID that was served, to reproduce it with --id",
+		},
+		{
+			name:        "timeout flag",
+			flagName:    "timeout",
+			flagType:    "*cli.DurationFlag",
+			aliases:     []string{},
+			description: "maximum time for each HTTP request including the download, 0 for no limit",
+		},
+		{
+			name:        "connect-timeout flag",
+			flagName:    "connect-timeout",
+			flagType:    "*cli.DurationFlag",
+			aliases:     []string{},
+			description: "maximum time to establish a connection, 0 for no limit",
+		},
+		{
+			name:        "retries flag",
+			flagName:    "retries",
+			flagType:    "*cli.IntFlag",
+			aliases:     []string{},
+			description: "number of times to retry a request after a network error, 429 or 5xx response",
+		},
+		{
+			name:        "retry-max-wait flag",
+			flagName:    "retry-max-wait",
+			flagType:    "*cli.DurationFlag",
+			aliases:     []string{},
+			description: "longest wait between retries, also the longest Retry-After honoured",
+		},
+		{
+			name:        "base-url flag",
+			flagName:    "base-url",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "root URL of a Lorem Picsum mirror, may include a path prefix",
+		},
+		{
+			name:        "cache-dir flag",
+			flagName:    "cache-dir",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "directory of the cache of --id and --seed responses (default: picsum in the user cache directory)",
+		},
+		{
+			name:        "offline flag",
+			flagName:    "offline",
+			flagType:    "*cli.BoolFlag",
+			aliases:     []string{},
+			description: "serve images from the cache only, fail instead of fetching",
+		},
+		{
+			name:        "no-cache flag",
+			flagName:    "no-cache",
+			flagType:    "*cli.BoolFlag",
+			aliases:     []string{},
+			description: "neither read nor write the cache",
+		},
+		{
+			name:        "provider flag",
+			flagName:    "provider",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "image source: picsum, local to generate images without a network, or a provider of --provider-config",
+		},
+		{
+			name:        "provider-config flag",
+			flagName:    "provider-config",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "JSON file defining templated URL providers (default: picsum/providers.json in the user config directory)",
+		},
+		{
+			name:        "format flag",
+			flagName:    "format",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "image format to request and save: jpg or webp (default: the server default, saved as .jpg)",
+		},
+		{
+			name:        "convert flag",
+			flagName:    "convert",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "re-encode the image as png, gif or jpeg before saving (default: the format of the --output extension)",
+		},
+		{
+			name:        "quality flag",
+			flagName:    "quality",
+			flagType:    "*cli.IntFlag",
+			aliases:     []string{},
+			description: "JPEG quality 1-100 of the re-encoded image, implies --convert jpeg, 0 for the encoder default of 75",
+		},
+		{
+			name:        "resize flag",
+			flagName:    "resize",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "resize the image to WxH before saving, up to 10000x10000",
+		},
+		{
+			name:        "crop flag",
+			flagName:    "crop",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "crop the WxH region at offset X,Y of the downloaded image, as WxH+X+Y, before --resize",
+		},
+		{
+			name:        "fit flag",
+			flagName:    "fit",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "keep the aspect ratio in --resize: cover to fill WxH and crop the overflow, contain to fit inside WxH and pad (default: stretch to WxH)",
+		},
+		{
+			name:        "pad-color flag",
+			flagName:    "pad-color",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "color of the padding of --fit contain, as #rgb or #rrggbb (default: #ffffff)",
+		},
+		{
+			name:        "filter flag",
+			flagName:    "filter",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "comma-separated effects applied in order after download: sepia, invert, tint=#rrggbb, brightness=F, contrast=F (F of 1 keeps the image)",
+		},
+		{
+			name:        "no-validate flag",
+			flagName:    "no-validate",
+			flagType:    "*cli.BoolFlag",
+			aliases:     []string{},
+			description: "save the download without checking that it is an image of the requested size",
+		},
+		{
+			name:        "checksum-file flag",
+			flagName:    "checksum-file",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "append the SHA-256 of every saved image to this file in sha256sum format, read by verify",
+		},
+		{
+			name:        "input flag",
+			flagName:    "input",
+			flagType:    "*cli.StringFlag",
+			aliases:     []string{},
+			description: "file of download jobs, one command line such as '200 300 --id 237 -o hero.jpg' per line, or - for standard input",
+		},
 		{
 			name:        "build flag",
 			flagName:    "build",
@@ -165,6 +349,10 @@ func TestBuildFlags(t *testing.T) {
 				if _, ok := flag.(*cli.IntFlag); !ok {
 					t.Errorf("flag %q is not an IntFlag", tt.flagName)
 				}
+			case "*cli.DurationFlag":
+				if _, ok := flag.(*cli.DurationFlag); !ok {
+					t.Errorf("flag %q is not a DurationFlag", tt.flagName)
+				}
 			}
 		})
 	}
@@ -232,6 +420,24 @@ func TestRunAction_OptionsValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "mutually exclusive",
 		},
+		{
+			name:    "count combined with id",
+			args:    []string{"picsum", "--count", "3", "--id", "123", "200"},
+			wantErr: true,
+			errMsg:  "cannot be combined",
+		},
+		{
+			name:    "negative count",
+			args:    []string{"picsum", "--count", "-2", "200"},
+			wantErr: true,
+			errMsg:  "count must not be negative",
+		},
+		{
+			name:    "negative timeout",
+			args:    []string{"picsum", "--timeout", "-1s", "200"},
+			wantErr: true,
+			errMsg:  "timeouts must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -427,15 +633,40 @@ func TestBuildFlags_Coverage(t *testing.T) {
 
 	// Test that all expected flags are present by name
 	expectedFlags := map[string]bool{
-		"id":        false,
-		"seed":      false,
-		"gray":      false,
-		"blur":      false,
-		"blurlevel": false,
-		"quiet":     false,
-		"output":    false,
-		"force":     false,
-		"build":     false,
+		"id":              false,
+		"seed":            false,
+		"gray":            false,
+		"blur":            false,
+		"blurlevel":       false,
+		"quiet":           false,
+		"output":          false,
+		"force":           false,
+		"count":           false,
+		"concurrency":     false,
+		"metadata":        false,
+		"print-id":        false,
+		"timeout":         false,
+		"connect-timeout": false,
+		"retries":         false,
+		"retry-max-wait":  false,
+		"base-url":        false,
+		"cache-dir":       false,
+		"offline":         false,
+		"no-cache":        false,
+		"provider":        false,
+		"provider-config": false,
+		"format":          false,
+		"convert":         false,
+		"quality":         false,
+		"no-validate":     false,
+		"resize":          false,
+		"crop":            false,
+		"fit":             false,
+		"pad-color":       false,
+		"filter":          false,
+		"checksum-file":   false,
+		"input":           false,
+		"build":           false,
 	}
 
 	for _, flag := range flags {
@@ -458,7 +689,7 @@ func TestBuildFlags_Coverage(t *testing.T) {
 func TestBuildFlags_StringFlagDefaults(t *testing.T) {
 	flags := buildFlags()
 
-	stringFlags := []string{"id", "seed", "output"}
+	stringFlags := []string{"id", "seed", "output", "name-template", "base-url", "cache-dir", "provider", "provider-config", "format", "convert", "resize", "crop", "fit", "pad-color", "filter", "checksum-file", "input"}
 	for _, flagName := range stringFlags {
 		found := false
 		for _, flag := range flags {
@@ -482,7 +713,7 @@ func TestBuildFlags_StringFlagDefaults(t *testing.T) {
 func TestBuildFlags_BoolFlagDefaults(t *testing.T) {
 	flags := buildFlags()
 
-	boolFlags := []string{"gray", "blur", "quiet", "force", "build"}
+	boolFlags := []string{"gray", "blur", "quiet", "force", "metadata", "print-id", "no-validate", "offline", "no-cache", "build"}
 	for _, flagName := range boolFlags {
 		found := false
 		for _, flag := range flags {
@@ -506,7 +737,7 @@ func TestBuildFlags_BoolFlagDefaults(t *testing.T) {
 func TestBuildFlags_IntFlagDefaults(t *testing.T) {
 	flags := buildFlags()
 
-	intFlags := []string{"blurlevel"}
+	intFlags := []string{"blurlevel", "count", "concurrency", "retries", "quality"}
 	for _, flagName := range intFlags {
 		found := false
 		for _, flag := range flags {
@@ -557,15 +788,40 @@ func TestBuildFlags_AllAliases(t *testing.T) {
 	flags := buildFlags()
 
 	expectedAliases := map[string][]string{
-		"id":        {"i"},
-		"seed":      {"s"},
-		"gray":      {"g"},
-		"blur":      {"b"},
-		"blurlevel": {"B"},
-		"quiet":     {"q"},
-		"output":    {"o"},
-		"force":     {"f"},
-		"build":     {},
+		"id":              {"i"},
+		"seed":            {"s"},
+		"gray":            {"g"},
+		"blur":            {"b"},
+		"blurlevel":       {"B"},
+		"quiet":           {"q"},
+		"output":          {"o"},
+		"force":           {"f"},
+		"count":           {"n"},
+		"concurrency":     {"c"},
+		"metadata":        {"m"},
+		"print-id":        {},
+		"timeout":         {},
+		"connect-timeout": {},
+		"retries":         {},
+		"retry-max-wait":  {},
+		"base-url":        {},
+		"cache-dir":       {},
+		"offline":         {},
+		"no-cache":        {},
+		"provider":        {},
+		"provider-config": {},
+		"format":          {},
+		"convert":         {},
+		"quality":         {},
+		"no-validate":     {},
+		"resize":          {},
+		"crop":            {},
+		"fit":             {},
+		"pad-color":       {},
+		"filter":          {},
+		"checksum-file":   {},
+		"input":           {},
+		"build":           {},
 	}
 
 	for _, flag := range flags {
@@ -614,6 +870,8 @@ func TestBuildFlags_EachFlagHasUsage(t *testing.T) {
 			usage = f.Usage
 		case *cli.IntFlag:
 			usage = f.Usage
+		case *cli.DurationFlag:
+			usage = f.Usage
 		}
 
 		if usage == "" {
@@ -648,3 +906,38 @@ func TestRunAction_BuildFlag(t *testing.T) {
 		t.Errorf("Expected build info output, got: %q", output)
 	}
 }
+
+// Test an invalid --base-url is rejected before any request is made
+func TestRunAction_InvalidBaseURL(t *testing.T) {
+	cmd := BuildCommand()
+
+	err := cmd.Run(context.Background(), []string{"picsum", "--base-url", "ftp://picsum.example.com", "300"})
+	if err == nil || !contains(err.Error(), "invalid base URL") {
+		t.Errorf("Expected invalid base URL error, got %v", err)
+	}
+}
+
+// Test --base-url applies to the URLs built by subcommands
+func TestBuildCommand_BaseURLAppliesToSubcommands(t *testing.T) {
+	var got string
+	cmd := BuildCommand()
+	for _, sub := range cmd.Commands {
+		if sub.Name == "info" {
+			sub.Action = func(_ context.Context, c *cli.Command) error {
+				p, _, err := newSource(c)
+				if err != nil {
+					return err
+				}
+				got, err = p.BuildURL(provider.Request{Args: []string{"200"}})
+				return err
+			}
+		}
+	}
+
+	if err := cmd.Run(context.Background(), []string{"picsum", "--provider", "local", "--base-url", "http://localhost:8080/", "info", "--id", "1"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got != "http://localhost:8080/200" {
+		t.Errorf("BuildURL() = %q, want %q", got, "http://localhost:8080/200")
+	}
+}
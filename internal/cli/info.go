@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/urfave/cli/v3"
+)
+
+// infoCommand creates the subcommand that prints the metadata of an image
+func infoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "info",
+		Usage: "show author and original dimensions of an image",
+		Description: "Print the metadata of the image selected with --id or --seed:\n" +
+			"  picsum info --id 237\n" +
+			"  picsum info --seed picsum --output-format json",
+		Flags:  infoFlags(),
+		Action: infoAction,
+	}
+}
+
+func infoFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "output-format",
+			Usage: "output format: text or json",
+			Value: imageinfo.FormatText,
+		},
+	}
+}
+
+func infoAction(ctx context.Context, c *cli.Command) error {
+	opts := &arguments.Options{
+		ImageID: c.String("id"),
+		Seed:    c.String("seed"),
+	}
+	if err := arguments.ValidateOptions(opts); err != nil {
+		return err
+	}
+
+	format := c.String("output-format")
+	if err := imageinfo.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	p, client, err := newSource(c)
+	if err != nil {
+		return err
+	}
+	info, err := p.Info(ctx, client, opts.ImageID, opts.Seed)
+	if err != nil {
+		return err
+	}
+	return imageinfo.Write(os.Stdout, info, format)
+}
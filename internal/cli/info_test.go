@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInfoCommand(t *testing.T) {
+	cmd := infoCommand()
+
+	if cmd.Name != "info" {
+		t.Errorf("infoCommand() Name = %v, want %v", cmd.Name, "info")
+	}
+	if cmd.Action == nil {
+		t.Error("infoCommand() Action is nil")
+	}
+	if len(cmd.Flags) != 1 || cmd.Flags[0].Names()[0] != "output-format" {
+		t.Errorf("infoCommand() should only declare the output-format flag")
+	}
+}
+
+func TestBuildCommand_HasInfoSubcommand(t *testing.T) {
+	cmd := BuildCommand()
+	if cmd.Command("info") == nil {
+		t.Error("BuildCommand() should have an info subcommand")
+	}
+}
+
+func TestInfoAction_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{
+			name:   "missing id and seed",
+			args:   []string{"picsum", "info"},
+			errMsg: "one of --id or --seed is required",
+		},
+		{
+			name:   "both id and seed",
+			args:   []string{"picsum", "info", "--id", "237", "--seed", "hello"},
+			errMsg: "mutually exclusive",
+		},
+		{
+			name:   "unsupported format",
+			args:   []string{"picsum", "info", "--id", "237", "--output-format", "xml"},
+			errMsg: "unsupported format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildCommand().Run(context.Background(), tt.args)
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+	"github.com/siakhooi/picsum/internal/batch"
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/jobfile"
+	"github.com/siakhooi/picsum/internal/output"
+	"github.com/urfave/cli/v3"
+)
+
+// sessionFlags configure the HTTP client or the run itself, they are shared by every --input job
+var sessionFlags = []string{
+	"timeout", "connect-timeout", "retries", "retry-max-wait", "base-url",
+	"cache-dir", "offline", "no-cache", "provider", "provider-config", "input", "build",
+}
+
+// singleImageFlags select or name one image, on the command line they would apply to every --input job alike
+var singleImageFlags = []string{"output", "id", "seed"}
+
+// inputJob is a validated line of the --input file
+type inputJob struct {
+	line int
+	args []string
+	opts *arguments.Options
+}
+
+// jobFlags reads the flags of a job line, falling back to the command line for the flags it does not set
+type jobFlags struct {
+	line, root *cli.Command
+}
+
+func (f jobFlags) pick(name string) *cli.Command {
+	if f.line.IsSet(name) {
+		return f.line
+	}
+	return f.root
+}
+
+func (f jobFlags) String(name string) string          { return f.pick(name).String(name) }
+func (f jobFlags) Bool(name string) bool              { return f.pick(name).Bool(name) }
+func (f jobFlags) Int(name string) int                { return f.pick(name).Int(name) }
+func (f jobFlags) Duration(name string) time.Duration { return f.pick(name).Duration(name) }
+
+/*
+runInput downloads the image of every line of --input. All lines are
+validated before the first download, then run by --concurrency workers.
+*/
+func runInput(ctx context.Context, c *cli.Command) error {
+	if c.Args().Present() {
+		return fmt.Errorf("positional arguments cannot be combined with --input")
+	}
+	for _, name := range singleImageFlags {
+		if c.IsSet(name) {
+			return fmt.Errorf("option --%s cannot be combined with --input, set it on the lines instead", name)
+		}
+	}
+	opts := newOptions(c)
+	if err := arguments.ValidateOptions(opts); err != nil {
+		return err
+	}
+
+	lines, err := jobfile.Load(c.String("input"))
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("no jobs found in input %s", c.String("input"))
+	}
+
+	jobs := make([]*inputJob, len(lines))
+	outputs := map[string]int{}
+	invalid := 0
+	for i, line := range lines {
+		if jobs[i], err = parseJob(ctx, c, line); err != nil {
+			console.Stderrln("line %d: %v", line.Line, err)
+			invalid++
+			continue
+		}
+		if err := claimOutputs(outputs, jobs[i]); err != nil {
+			console.Stderrln("line %d: %v", line.Line, err)
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d jobs are invalid, nothing was downloaded", invalid, len(lines))
+	}
+
+	client, err := arguments.NewHTTPClient(opts)
+	if err != nil {
+		return err
+	}
+
+	total := len(jobs)
+	errs := batch.Run(total, opts.Concurrency, func(i int) error {
+		err := arguments.ProcessImageWithClient(ctx, client, jobs[i].args, jobs[i].opts)
+		if err != nil {
+			console.Stderrln("line %d failed: %v", jobs[i].line, err)
+		}
+		return err
+	})
+
+	failed := batch.CountFailed(errs)
+	if !opts.Quiet {
+		console.Stdoutln("Completed %d of %d jobs", total-failed, total)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs failed", failed, total)
+	}
+	return nil
+}
+
+/*
+claimOutputs records the files job saves in outputs, failing when an earlier
+line already saves one of them. The names are compared once the name template
+is expanded, so default names and templates are checked like --output.
+*/
+func claimOutputs(outputs map[string]int, job *inputJob) error {
+	names, err := arguments.TargetFilenames(job.args, job.opts)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if first, ok := outputs[filepath.Clean(name)]; ok {
+			return fmt.Errorf("output %s is already written by line %d", name, first)
+		}
+	}
+	for _, name := range names {
+		outputs[filepath.Clean(name)] = job.line
+	}
+	return nil
+}
+
+/*
+parseJob parses a line with the flags of the command and validates it like
+a command line. Flags the line does not set are taken from the command line.
+*/
+func parseJob(ctx context.Context, root *cli.Command, job jobfile.Job) (*inputJob, error) {
+	var line *cli.Command
+	parser := &cli.Command{
+		Name:        root.Name,
+		HideHelp:    true,
+		HideVersion: true,
+		Flags:       lineFlags(),
+		OnUsageError: func(_ context.Context, _ *cli.Command, err error, _ bool) error {
+			return err
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			line = c
+			return nil
+		},
+	}
+	if err := parser.Run(ctx, append([]string{root.Name}, job.Args...)); err != nil {
+		return nil, err
+	}
+
+	for _, name := range sessionFlags {
+		if line.IsSet(name) {
+			return nil, fmt.Errorf("option --%s applies to all jobs and cannot be set on a line", name)
+		}
+	}
+
+	args := line.Args().Slice()
+	if err := arguments.ValidateArguments(args); err != nil {
+		return nil, err
+	}
+	opts := newOptions(jobFlags{line: line, root: root})
+	if opts.OutputPath == output.StdoutPath {
+		return nil, fmt.Errorf("option --output - cannot be used with --input")
+	}
+	if err := arguments.ValidateOptions(opts); err != nil {
+		return nil, err
+	}
+	opts.IDPrefix = fmt.Sprintf("line %d: ", job.Line)
+	return &inputJob{line: job.Line, args: args, opts: opts}, nil
+}
+
+// lineFlags returns the command flags without environment variable sources, so only the line itself sets them
+func lineFlags() []cli.Flag {
+	flags := buildFlags()
+	for _, flag := range flags {
+		if f, ok := flag.(*cli.StringFlag); ok {
+			f.Sources = cli.ValueSourceChain{}
+		}
+	}
+	return flags
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/jobfile"
+	"github.com/urfave/cli/v3"
+)
+
+// writeInput writes the job lines to a file in dir and returns its path
+func writeInput(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "jobs.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunInput_DownloadsEveryLine(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	input := writeInput(t, dir, "# heroes\n"+
+		"200 300 --id 237 -o "+filepath.Join(dir, "hero.jpg")+"\n"+
+		"\n"+
+		"100 -g --seed abc -o '"+filepath.Join(dir, "small thumb.jpg")+"'\n")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "--input", input})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, name := range []string{"hero.jpg", "small thumb.jpg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be saved: %v", name, err)
+		}
+	}
+}
+
+func TestRunInput_LinesInheritCommandLineFlags(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	input := writeInput(t, dir, "200 --id 1\n300 --id 2 -t "+filepath.Join(dir, "own-{id}.jpg")+"\n")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "-c", "1",
+		"-t", filepath.Join(dir, "shared-{id}.jpg"), "--input", input})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, name := range []string{"shared-1.jpg", "own-2.jpg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be saved: %v", name, err)
+		}
+	}
+}
+
+func TestRunInput_InvalidLineDownloadsNothing(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "first.jpg")
+	input := writeInput(t, dir, "200 -o "+saved+"\n--id 237\n")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "--input", input})
+
+	// THEN
+	if err == nil || err.Error() != "1 of 2 jobs are invalid, nothing was downloaded" {
+		t.Errorf("Expected invalid job count, got %v", err)
+	}
+	if _, statErr := os.Stat(saved); statErr == nil {
+		t.Error("Expected no download when a line is invalid")
+	}
+}
+
+func TestParseJob_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"missing size", []string{"--id", "237"}, "invalid arguments"},
+		{"mutually exclusive options", []string{"200", "--id", "1", "--seed", "a"}, "mutually exclusive"},
+		{"unknown flag", []string{"200", "--nope"}, "flag provided but not defined"},
+		{"invalid int", []string{"200", "-B", "x"}, "invalid value"},
+		{"blur level out of range", []string{"200", "-B", "11"}, "blur level must be between 1 and 10"},
+		{"session flag", []string{"200", "--offline"}, "option --offline applies to all jobs"},
+		{"standard output", []string{"200", "-o", "-"}, "option --output - cannot be used with --input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			root := BuildCommand()
+
+			// WHEN
+			_, err := parseJob(context.Background(), root, jobfile.Job{Line: 1, Args: tt.args})
+
+			// THEN
+			if err == nil || !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestParseJob_LineOverridesCommandLine(t *testing.T) {
+	// GIVEN
+	var job *inputJob
+	var err error
+	root := BuildCommand()
+	root.Action = func(ctx context.Context, c *cli.Command) error {
+		job, err = parseJob(ctx, c, jobfile.Job{Line: 3, Args: []string{"200", "300", "-g", "-n", "2"}})
+		return nil
+	}
+
+	// WHEN
+	if runErr := root.Run(context.Background(), []string{"picsum", "-q", "-n", "5", "-t", "{n}.jpg"}); runErr != nil {
+		t.Fatalf("Run failed: %v", runErr)
+	}
+
+	// THEN
+	if err != nil {
+		t.Fatalf("parseJob() unexpected error: %v", err)
+	}
+	if job.line != 3 || len(job.args) != 2 {
+		t.Errorf("parseJob() line = %d args = %v, want line 3 and 2 args", job.line, job.args)
+	}
+	opts := job.opts
+	if !opts.Grayscale || opts.Count != 2 {
+		t.Errorf("Expected line flags gray and count=2, got %+v", opts)
+	}
+	if !opts.Quiet || opts.NameTemplate != "{n}.jpg" {
+		t.Errorf("Expected command line flags quiet and name template, got %+v", opts)
+	}
+}
+
+func TestRunInput_ReportsFailedJobs(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	input := writeInput(t, dir, "200 -o "+filepath.Join(dir, "ok.jpg")+"\n"+
+		"200 -o "+filepath.Join(dir, "missing", "fail.jpg")+"\n")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "--input", input})
+
+	// THEN
+	if err == nil || err.Error() != "1 of 2 jobs failed" {
+		t.Errorf("Expected 1 of 2 jobs failed, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "ok.jpg")); statErr != nil {
+		t.Errorf("Expected the valid job to be saved: %v", statErr)
+	}
+}
+
+func TestRunInput_Validation(t *testing.T) {
+	dir := t.TempDir()
+	empty := writeInput(t, dir, "# nothing\n\n")
+	unquoted := filepath.Join(dir, "unquoted.txt")
+	if err := os.WriteFile(unquoted, []byte("200 -o 'a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"positional arguments", []string{"picsum", "--input", empty, "200"}, "positional arguments cannot be combined with --input"},
+		{"no jobs", []string{"picsum", "--input", empty}, "no jobs found in input"},
+		{"missing file", []string{"picsum", "--input", filepath.Join(dir, "missing.txt")}, "failed to open input"},
+		{"unterminated quote", []string{"picsum", "--input", unquoted}, "line 1: unterminated ' quote"},
+		{"invalid options", []string{"picsum", "--offline", "--no-cache", "--input", empty}, "mutually exclusive"},
+		{"shared output", []string{"picsum", "-o", "same.jpg", "--input", empty}, "option --output cannot be combined with --input"},
+		{"shared id", []string{"picsum", "--id", "237", "--input", empty}, "option --id cannot be combined with --input"},
+		{"shared seed", []string{"picsum", "--seed", "abc", "--input", empty}, "option --seed cannot be combined with --input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildCommand().Run(context.Background(), tt.args)
+			if err == nil || !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestRunInput_SameOutputOnTwoLinesDownloadsNothing(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "same.jpg")
+	input := writeInput(t, dir, "200 -o "+saved+"\n300 -o "+filepath.Join(dir, ".", "same.jpg")+"\n")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "-f", "--input", input})
+
+	// THEN
+	if err == nil || err.Error() != "1 of 2 jobs are invalid, nothing was downloaded" {
+		t.Errorf("Expected invalid job count, got %v", err)
+	}
+	if _, statErr := os.Stat(saved); statErr == nil {
+		t.Error("Expected no download when two lines write the same file")
+	}
+}
+
+func TestRunInput_SameResolvedNameOnTwoLinesDownloadsNothing(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		lines string
+	}{
+		{"default name", nil, "200 --id 237\n200 --id 237\n"},
+		{"template without n", []string{"--name-template", "img.jpg"}, "200\n300 --id 7\n"},
+		{"template and output", []string{"--name-template", "{width}.jpg"}, "200\n300 -o 200.jpg\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			dir := t.TempDir()
+			t.Chdir(dir)
+			input := writeInput(t, dir, tt.lines)
+			args := append([]string{"picsum", "--provider", "local", "-q", "-f"}, tt.flags...)
+
+			// WHEN
+			err := BuildCommand().Run(context.Background(), append(args, "--input", input))
+
+			// THEN
+			if err == nil || err.Error() != "1 of 2 jobs are invalid, nothing was downloaded" {
+				t.Errorf("Expected invalid job count, got %v", err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("Expected no download when two lines write the same file, got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestRunInput_ServedIDTemplateIsNotACollision(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	input := writeInput(t, dir, "200\n200\n")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "-f",
+		"--name-template", filepath.Join(dir, "{id}-{n}.jpg"), "--input", input})
+
+	// THEN
+	if err != nil {
+		t.Errorf("Expected names resolved from the served image to be allowed, got %v", err)
+	}
+}
+
+func TestRunInput_PrintIDPrefixesLine(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	input := writeInput(t, dir, "# ids\n200 --id 7 -o "+filepath.Join(dir, "a.jpg")+"\n")
+	r, w, _ := os.Pipe()
+	origStdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = origStdout }()
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--provider", "local", "-q", "--print-id", "--input", input})
+	_ = w.Close()
+	var outBuf [256]byte
+	n, _ := r.Read(outBuf[:])
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := string(outBuf[:n]); got != "line 2: 7\n" {
+		t.Errorf("Expected the ID prefixed with its line, got %q", got)
+	}
+}
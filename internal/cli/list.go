@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/siakhooi/picsum/internal/catalog"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/provider"
+	"github.com/urfave/cli/v3"
+)
+
+// listCommand creates the subcommand that prints the picsum.photos catalog
+func listCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "list images available on https://picsum.photos",
+		Description: "Print id, author, original width/height and source URL of catalog images.\n" +
+			"Use the id with --id to download a specific image.",
+		Flags:  listFlags(),
+		Action: listAction,
+	}
+}
+
+func listFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "page",
+			Usage: "page number to start listing from",
+			Value: 1,
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "number of images per page (max 100)",
+			Value: 30,
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "keep fetching pages until the end of the catalog",
+		},
+		&cli.StringFlag{
+			Name:  "output-format",
+			Usage: "output format: table, json or csv",
+			Value: catalog.FormatTable,
+		},
+	}
+}
+
+func listAction(ctx context.Context, c *cli.Command) error {
+	page, limit, format := c.Int("page"), c.Int("limit"), c.String("output-format")
+
+	if err := catalog.ValidatePaging(page, limit); err != nil {
+		return err
+	}
+	if err := catalog.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	p, client, err := newSource(c)
+	if err != nil {
+		return err
+	}
+	var images []imageinfo.Info
+	if c.Bool("all") {
+		images, err = provider.ListAll(ctx, p, client, page, limit)
+	} else {
+		images, err = p.List(ctx, client, page, limit)
+	}
+	if err != nil {
+		return err
+	}
+	return catalog.Write(os.Stdout, images, format)
+}
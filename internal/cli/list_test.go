@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListCommand(t *testing.T) {
+	cmd := listCommand()
+
+	if cmd.Name != "list" {
+		t.Errorf("listCommand() Name = %v, want %v", cmd.Name, "list")
+	}
+	if cmd.Action == nil {
+		t.Error("listCommand() Action is nil")
+	}
+
+	expectedFlags := map[string]bool{"page": false, "limit": false, "all": false, "output-format": false}
+	for _, flag := range cmd.Flags {
+		expectedFlags[flag.Names()[0]] = true
+	}
+	for name, found := range expectedFlags {
+		if !found {
+			t.Errorf("Expected flag %q was not found in listFlags()", name)
+		}
+	}
+}
+
+func TestBuildCommand_HasListSubcommand(t *testing.T) {
+	cmd := BuildCommand()
+	if cmd.Command("list") == nil {
+		t.Error("BuildCommand() should have a list subcommand")
+	}
+}
+
+func TestListAction_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{
+			name:   "invalid page",
+			args:   []string{"picsum", "list", "--page", "0"},
+			errMsg: "page must be at least 1",
+		},
+		{
+			name:   "limit too high",
+			args:   []string{"picsum", "list", "--limit", "101"},
+			errMsg: "limit must be between 1 and 100",
+		},
+		{
+			name:   "unsupported format",
+			args:   []string{"picsum", "list", "--output-format", "xml"},
+			errMsg: "unsupported format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildCommand().Run(context.Background(), tt.args)
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
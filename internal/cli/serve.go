@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"net"
+
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/server"
+	"github.com/urfave/cli/v3"
+)
+
+// serveCommand creates the subcommand that serves picsum.photos image URLs locally
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "serve picsum.photos image URLs from the local cache",
+		Description: "Answer /{width}/{height}, /id/{id}/{width}/{height} and /seed/{seed}/{width}/{height}\n" +
+			"with the ?grayscale and ?blur options, fetching upstream on a cache miss:\n" +
+			"  picsum serve --addr localhost:9000\n" +
+			"  picsum --offline serve",
+		Flags:  serveFlags(),
+		Action: serveAction,
+	}
+}
+
+func serveFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "address to listen on, :8080 to accept connections from other machines",
+			Value: server.DefaultAddr,
+		},
+	}
+}
+
+func serveAction(ctx context.Context, c *cli.Command) error {
+	p, client, err := newSource(c)
+	if err != nil {
+		return err
+	}
+
+	quiet := c.Bool("quiet")
+	handler := server.NewHandler(p, client, quiet)
+	return server.ListenAndServe(ctx, c.String("addr"), handler, func(addr net.Addr) {
+		if !quiet {
+			console.Stdoutln("Serving picsum URLs on http://%s", addr)
+		}
+	})
+}
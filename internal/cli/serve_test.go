@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestServeCommand(t *testing.T) {
+	cmd := serveCommand()
+
+	if cmd.Name != "serve" {
+		t.Errorf("serveCommand() Name = %v, want %v", cmd.Name, "serve")
+	}
+	if cmd.Action == nil {
+		t.Error("serveCommand() Action is nil")
+	}
+	if len(cmd.Flags) != 1 || cmd.Flags[0].Names()[0] != "addr" {
+		t.Errorf("serveCommand() should only declare the addr flag")
+	}
+}
+
+func TestBuildCommand_HasServeSubcommand(t *testing.T) {
+	if BuildCommand().Command("serve") == nil {
+		t.Error("BuildCommand() should have a serve subcommand")
+	}
+}
+
+func TestServeAction_InvalidAddress(t *testing.T) {
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--no-cache", "serve", "--addr", "invalid:address:1"})
+	if err == nil || !contains(err.Error(), "failed to listen") {
+		t.Errorf("Expected listen error, got %v", err)
+	}
+}
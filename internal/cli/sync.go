@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+	"github.com/siakhooi/picsum/internal/batch"
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/manifest"
+	"github.com/urfave/cli/v3"
+)
+
+// syncCommand creates the subcommand that materialises the images of a manifest
+func syncCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "sync",
+		Usage:     "download the images of a YAML or JSON manifest that are missing or out of date",
+		ArgsUsage: "<manifest>",
+		Description: "Materialise a set of named images, e.g. test fixtures:\n" +
+			"  images:\n" +
+			"    - name: hero\n" +
+			"      width: 1200\n" +
+			"      height: 400\n" +
+			"      id: 237\n" +
+			"      output: fixtures/hero.jpg\n" +
+			"Each image takes name, width, height, id, seed, gray, blur (level 1-10), format and output,\n" +
+			"relative to the manifest. The image ID and SHA-256 each entry resolves to are pinned in\n" +
+			manifest.LockFilename + " next to the manifest; later runs fetch the pinned ID, skip files with\n" +
+			"the pinned checksum and fail if a download no longer matches it.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "update-lock",
+				Usage: "resolve every image again and rewrite " + manifest.LockFilename,
+			},
+		},
+		Action: syncAction,
+	}
+}
+
+// syncResult is the outcome of syncing a single image
+type syncResult struct {
+	pin      manifest.Pin
+	current  bool
+	resolved bool
+}
+
+func syncAction(ctx context.Context, c *cli.Command) error {
+	path, err := fileArgument(c)
+	if err != nil {
+		return err
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		return err
+	}
+	lock, err := manifest.LoadLock(m.LockPath())
+	if err != nil {
+		return err
+	}
+
+	base := sourceOptions(c)
+	if err := arguments.ValidateOptions(base); err != nil {
+		return err
+	}
+	client, err := arguments.NewHTTPClient(base)
+	if err != nil {
+		return err
+	}
+	base.Quiet = true
+	base.Force = true
+	quiet := c.Bool("quiet")
+	update := c.Bool("update-lock")
+
+	var current atomic.Int32
+	total := len(m.Images)
+	results := make([]syncResult, total)
+	errs := batch.Run(total, c.Int("concurrency"), func(i int) error {
+		img := m.Images[i]
+		pin, pinned := lock.Images[img.Name]
+		if update || !pin.Matches(img) {
+			pinned = false
+		}
+
+		if pinned && m.Verify(img, pin) {
+			current.Add(1)
+			results[i] = syncResult{pin: pin, current: true}
+			if !quiet {
+				console.Stdoutln("%s: up to date", img.Name)
+			}
+			return nil
+		}
+
+		var err error
+		if pinned {
+			err = fetchPinned(ctx, client, m, img, pin, *base)
+		} else {
+			results[i].pin, err = resolve(ctx, client, m, img, *base)
+			results[i].resolved = err == nil
+		}
+		switch {
+		case err != nil:
+			console.Stderrln("%s: failed: %v", img.Name, err)
+		case !quiet:
+			console.Stdoutln("%s: saved %s", img.Name, m.Path(img))
+		}
+		return err
+	})
+
+	if err := writeLock(m, lock, results); err != nil {
+		return err
+	}
+
+	failed := batch.CountFailed(errs)
+	if !quiet {
+		console.Stdoutln("Synced %d of %d images, %d already up to date", total-failed, total, current.Load())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed", failed, total)
+	}
+	return nil
+}
+
+// resolve downloads an image as the manifest describes it and pins what was served
+func resolve(ctx context.Context, client httpclient.Getter, m *manifest.Manifest, img manifest.Image, base arguments.Options) (manifest.Pin, error) {
+	saved, err := syncImage(ctx, client, img, m.Options(img, base))
+	if err != nil {
+		return manifest.Pin{}, err
+	}
+	return manifest.Pin{Request: img.Request(), ID: saved.ImageID, FinalURL: saved.FinalURL, SHA256: saved.SHA256}, nil
+}
+
+/*
+fetchPinned downloads the pinned image into a temporary directory next to
+its file and only moves it into place once it matches the pinned checksum,
+so a drifted download never replaces the last good copy
+*/
+func fetchPinned(ctx context.Context, client httpclient.Getter, m *manifest.Manifest, img manifest.Image, pin manifest.Pin, base arguments.Options) error {
+	opts := m.Options(img, base)
+	pin.Apply(opts)
+	target := opts.OutputPath
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(target), ".sync-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	opts.OutputPath = filepath.Join(tmpDir, filepath.Base(target))
+	saved, err := arguments.SaveImageWithClient(ctx, client, img.Args(), opts)
+	if err != nil {
+		return err
+	}
+	if saved.SHA256 != pin.SHA256 {
+		return fmt.Errorf("checksum drift, %s pins sha256 %s but the download has %s, run sync --update-lock to accept it",
+			manifest.LockFilename, pin.SHA256, saved.SHA256)
+	}
+	if info, err := os.Stat(target); err == nil {
+		if err := os.Chmod(saved.Filename, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %v", target, err)
+		}
+	}
+	if err := os.Rename(saved.Filename, target); err != nil {
+		return fmt.Errorf("failed to write %s: %v", target, err)
+	}
+	return nil
+}
+
+// syncImage downloads an image of the manifest, creating its directory as needed
+func syncImage(ctx context.Context, client httpclient.Getter, img manifest.Image, opts *arguments.Options) (*arguments.SavedImage, error) {
+	if err := os.MkdirAll(filepath.Dir(opts.OutputPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+	return arguments.SaveImageWithClient(ctx, client, img.Args(), opts)
+}
+
+/*
+writeLock pins the images resolved by this run, keeps the existing pins of
+the images that failed and drops the pins of images no longer in the
+manifest. The lockfile is only written when it changes.
+*/
+func writeLock(m *manifest.Manifest, lock *manifest.Lock, results []syncResult) error {
+	updated := &manifest.Lock{Images: map[string]manifest.Pin{}}
+	for i, img := range m.Images {
+		switch {
+		case results[i].resolved || results[i].current:
+			updated.Images[img.Name] = results[i].pin
+		default:
+			if pin, ok := lock.Images[img.Name]; ok {
+				updated.Images[img.Name] = pin
+			}
+		}
+	}
+	if reflect.DeepEqual(updated, lock) {
+		return nil
+	}
+	return updated.Write(m.LockPath())
+}
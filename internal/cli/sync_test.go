@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/manifest"
+)
+
+func TestSyncCommand(t *testing.T) {
+	cmd := syncCommand()
+
+	if cmd.Name != "sync" {
+		t.Errorf("syncCommand() Name = %v, want %v", cmd.Name, "sync")
+	}
+	if cmd.Action == nil {
+		t.Error("syncCommand() Action is nil")
+	}
+	if len(cmd.Flags) != 1 || cmd.Flags[0].Names()[0] != "update-lock" {
+		t.Errorf("syncCommand() should only declare the update-lock flag")
+	}
+}
+
+func TestBuildCommand_HasSyncSubcommand(t *testing.T) {
+	if BuildCommand().Command("sync") == nil {
+		t.Error("BuildCommand() should have a sync subcommand")
+	}
+}
+
+// writeFixtures writes a manifest of a pinned, a seeded and a random image to dir
+func writeFixtures(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "fixtures.yaml")
+	content := "images:\n" +
+		"  - {name: hero, width: 60, height: 40, id: 237, output: fixtures/hero.jpg}\n" +
+		"  - {name: avatar, width: 30, seed: alice, gray: true, blur: 2, output: avatar.jpg}\n" +
+		"  - {name: random, width: 20, output: random.jpg}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// syncFixtures runs sync on the manifest with the local provider
+func syncFixtures(path string, flags ...string) error {
+	args := append([]string{"picsum", "--provider", "local", "-q", "sync"}, flags...)
+	return BuildCommand().Run(context.Background(), append(args, path))
+}
+
+func TestSyncAction_WritesLockAndSkipsPinnedImages(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := writeFixtures(t, dir)
+
+	// WHEN
+	if err := syncFixtures(path); err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+	lock, err := manifest.LoadLock(filepath.Join(dir, manifest.LockFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hero := filepath.Join(dir, "fixtures", "hero.jpg")
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(hero, past, past); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"avatar.jpg", "random.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("corrupt"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := syncFixtures(path); err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+
+	// THEN
+	if len(lock.Images) != 3 {
+		t.Fatalf("Expected 3 pinned images, got %+v", lock.Images)
+	}
+	if pin := lock.Images["hero"]; pin.ID != "237" || pin.Request != "60 40 --id 237" || pin.SHA256 == "" || pin.FinalURL == "" {
+		t.Errorf("Unexpected hero pin %+v", pin)
+	}
+	if lock.Images["random"].ID == "" {
+		t.Errorf("Expected the random image to be pinned to an ID, got %+v", lock.Images["random"])
+	}
+	info, err := os.Stat(hero)
+	if err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("Expected the up to date hero image to be skipped, err %v", err)
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, img := range m.Images {
+		if !m.Verify(img, lock.Images[img.Name]) {
+			t.Errorf("Expected %s to be restored with the pinned checksum", img.Name)
+		}
+	}
+}
+
+func TestSyncAction_FailsOnChecksumDrift(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := writeFixtures(t, dir)
+	if err := syncFixtures(path); err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+	lockPath := filepath.Join(dir, manifest.LockFilename)
+	lock, err := manifest.LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drifted := lock.Images["hero"]
+	drifted.SHA256 = "0000"
+	lock.Images["hero"] = drifted
+	if err := lock.Write(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	hero := filepath.Join(dir, "fixtures", "hero.jpg")
+	if err := os.WriteFile(hero, []byte("last good copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	err = syncFixtures(path)
+
+	// THEN
+	if err == nil || err.Error() != "1 of 3 images failed" {
+		t.Errorf("Expected the drifted image to fail, got %v", err)
+	}
+	if data, readErr := os.ReadFile(hero); readErr != nil || string(data) != "last good copy" {
+		t.Errorf("Expected the drifted download to leave the existing file alone, got %q, %v", data, readErr)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(hero)); len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+	kept, _ := manifest.LoadLock(lockPath)
+	if kept.Images["hero"].SHA256 != "0000" {
+		t.Error("Expected the pin of the failed image to be kept")
+	}
+
+	// WHEN the drift is accepted
+	if err := syncFixtures(path, "--update-lock"); err != nil {
+		t.Fatalf("sync --update-lock failed: %v", err)
+	}
+
+	// THEN
+	updated, _ := manifest.LoadLock(lockPath)
+	if updated.Images["hero"].SHA256 == "0000" {
+		t.Error("Expected --update-lock to pin the new checksum")
+	}
+}
+
+func TestSyncAction_ResolvesChangedAndDropsRemovedImages(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := writeFixtures(t, dir)
+	if err := syncFixtures(path); err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+	content := "images:\n  - {name: hero, width: 80, height: 40, id: 237, output: fixtures/hero.jpg}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	err := syncFixtures(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	lock, _ := manifest.LoadLock(filepath.Join(dir, manifest.LockFilename))
+	if len(lock.Images) != 1 || lock.Images["hero"].Request != "80 40 --id 237" {
+		t.Errorf("Expected only the re-resolved hero pin, got %+v", lock.Images)
+	}
+}
+
+func TestSyncAction_ReportsFailedImages(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fixtures.yaml")
+	content := "images:\n" +
+		"  - {name: ok, width: 20, output: ok.jpg}\n" +
+		"  - {name: offline, width: 20, id: 1, output: offline.jpg}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "--offline", "--cache-dir", t.TempDir(), "-q", "sync", path})
+
+	// THEN
+	if err == nil || err.Error() != "2 of 2 images failed" {
+		t.Errorf("Expected 2 of 2 images failed, got %v", err)
+	}
+}
+
+func TestSyncAction_Validation(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "fixtures.yaml")
+	if err := os.WriteFile(valid, []byte("images:\n  - {name: a, width: 1, output: a.jpg}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"missing manifest argument", []string{"picsum", "sync"}, "expected exactly one file argument"},
+		{"missing manifest", []string{"picsum", "sync", filepath.Join(dir, "missing.yaml")}, "failed to open manifest"},
+		{"invalid options", []string{"picsum", "--offline", "--no-cache", "sync", valid}, "mutually exclusive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildCommand().Run(context.Background(), tt.args)
+			if err == nil || !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
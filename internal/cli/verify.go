@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/siakhooi/picsum/internal/checksum"
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/manifest"
+	"github.com/siakhooi/picsum/internal/sidecar"
+	"github.com/urfave/cli/v3"
+)
+
+// verifyCommand creates the subcommand that checks downloaded files against their recorded checksums
+func verifyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "check downloaded files against their recorded SHA-256",
+		ArgsUsage: "[file...]",
+		Description: "Check files against the SHA-256 recorded when they were saved:\n" +
+			"  picsum verify hero.jpg                         the --metadata sidecar hero.jpg.json\n" +
+			"  picsum verify --checksum-file SHA256SUMS       every entry of a sha256sum file\n" +
+			"  picsum verify --manifest fixtures.yaml         the images pinned in " + manifest.LockFilename + "\n" +
+			"Files given with --checksum-file are looked up in it before their sidecar.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "manifest",
+				Usage: "verify the images of a sync manifest against its " + manifest.LockFilename,
+			},
+		},
+		Action: verifyAction,
+	}
+}
+
+// expectation is a file and the checksum it was recorded with
+type expectation struct {
+	path   string
+	sha256 string
+	// err explains why no checksum is known
+	err error
+}
+
+func verifyAction(_ context.Context, c *cli.Command) error {
+	expected, err := collectExpectations(c)
+	if err != nil {
+		return err
+	}
+	if len(expected) == 0 {
+		return fmt.Errorf("nothing to verify, pass files, --checksum-file or --manifest")
+	}
+
+	quiet := c.Bool("quiet")
+	failed := 0
+	for _, e := range expected {
+		err := e.err
+		if err == nil {
+			err = checksum.Check(e.path, e.sha256)
+		}
+		switch {
+		case errors.Is(err, checksum.ErrMismatch):
+			console.Stdoutln("%s: FAILED", e.path)
+		case err != nil:
+			console.Stdoutln("%s: FAILED %v", e.path, err)
+		case !quiet:
+			console.Stdoutln("%s: OK", e.path)
+		}
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed verification", failed, len(expected))
+	}
+	return nil
+}
+
+// collectExpectations gathers the checksums of the manifest, the checksum file and the file arguments
+func collectExpectations(c *cli.Command) ([]expectation, error) {
+	var expected []expectation
+
+	if path := c.String("manifest"); path != "" {
+		m, err := manifest.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		lock, err := manifest.LoadLock(m.LockPath())
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range m.Images {
+			e := expectation{path: m.Path(img)}
+			if pin, ok := lock.Images[img.Name]; ok && pin.Matches(img) {
+				e.sha256 = pin.SHA256
+			} else {
+				e.err = fmt.Errorf("image %q is not pinned in %s", img.Name, manifest.LockFilename)
+			}
+			expected = append(expected, e)
+		}
+	}
+
+	sums := map[string]string{}
+	if path := c.String("checksum-file"); path != "" {
+		entries, err := checksum.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			sums[entry.Path] = entry.SHA256
+			if !c.Args().Present() {
+				expected = append(expected, expectation{path: entry.Path, sha256: entry.SHA256})
+			}
+		}
+	}
+
+	for _, path := range c.Args().Slice() {
+		if sum, ok := sums[path]; ok {
+			expected = append(expected, expectation{path: path, sha256: sum})
+			continue
+		}
+		e := expectation{path: path}
+		if metadata, err := sidecar.Read(path); err != nil {
+			e.err = fmt.Errorf("no recorded checksum: %v", err)
+		} else {
+			e.sha256 = metadata.SHA256
+		}
+		expected = append(expected, e)
+	}
+	return expected, nil
+}
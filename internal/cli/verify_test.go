@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	cmd := verifyCommand()
+
+	if cmd.Name != "verify" {
+		t.Errorf("verifyCommand() Name = %v, want %v", cmd.Name, "verify")
+	}
+	if cmd.Action == nil {
+		t.Error("verifyCommand() Action is nil")
+	}
+	if len(cmd.Flags) != 1 || cmd.Flags[0].Names()[0] != "manifest" {
+		t.Errorf("verifyCommand() should only declare the manifest flag")
+	}
+}
+
+func TestBuildCommand_HasVerifySubcommand(t *testing.T) {
+	if BuildCommand().Command("verify") == nil {
+		t.Error("BuildCommand() should have a verify subcommand")
+	}
+}
+
+// download saves a generated image with the given extra flags and fails the test on error
+func download(t *testing.T, path string, flags ...string) {
+	t.Helper()
+	args := append([]string{"picsum", "--provider", "local", "-q", "-f", "-o", path}, flags...)
+	if err := BuildCommand().Run(context.Background(), append(args, "40")); err != nil {
+		t.Fatalf("download of %s failed: %v", path, err)
+	}
+}
+
+func TestVerifyAction_Sidecar(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "hero.jpg")
+	download(t, path, "-m", "-s", "hero")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "verify", path})
+
+	// THEN
+	if err != nil {
+		t.Errorf("Expected the intact file to verify, got %v", err)
+	}
+
+	// WHEN
+	if err := os.WriteFile(path, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = BuildCommand().Run(context.Background(), []string{"picsum", "-q", "verify", path})
+
+	// THEN
+	if err == nil || err.Error() != "1 of 1 files failed verification" {
+		t.Errorf("Expected the corrupt file to fail, got %v", err)
+	}
+}
+
+func TestVerifyAction_ChecksumFile(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	sums := filepath.Join(dir, "SHA256SUMS")
+	first := filepath.Join(dir, "first.jpg")
+	second := filepath.Join(dir, "second.jpg")
+	download(t, first, "--checksum-file", sums, "-s", "a")
+	download(t, second, "--checksum-file", sums, "-s", "b")
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "verify", "--checksum-file", sums})
+
+	// THEN
+	if err != nil {
+		t.Errorf("Expected every entry to verify, got %v", err)
+	}
+
+	// WHEN
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	all := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "verify", "--checksum-file", sums})
+	selected := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "--checksum-file", sums, "verify", first})
+
+	// THEN
+	if all == nil || all.Error() != "1 of 2 files failed verification" {
+		t.Errorf("Expected the missing file to fail, got %v", all)
+	}
+	if selected != nil {
+		t.Errorf("Expected only the given file to be verified, got %v", selected)
+	}
+}
+
+func TestVerifyAction_Manifest(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := writeFixtures(t, dir)
+	if err := syncFixtures(path); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	// WHEN
+	err := BuildCommand().Run(context.Background(), []string{"picsum", "-q", "verify", "--manifest", path})
+
+	// THEN
+	if err != nil {
+		t.Errorf("Expected the synced images to verify, got %v", err)
+	}
+
+	// WHEN
+	if err := os.WriteFile(filepath.Join(dir, "avatar.jpg"), []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := "images:\n" +
+		"  - {name: hero, width: 60, height: 40, id: 237, output: fixtures/hero.jpg}\n" +
+		"  - {name: avatar, width: 30, seed: alice, gray: true, blur: 2, output: avatar.jpg}\n" +
+		"  - {name: new, width: 20, output: new.jpg}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = BuildCommand().Run(context.Background(), []string{"picsum", "-q", "verify", "--manifest", path})
+
+	// THEN
+	if err == nil || err.Error() != "2 of 3 files failed verification" {
+		t.Errorf("Expected the corrupt and the unpinned image to fail, got %v", err)
+	}
+}
+
+func TestVerifyAction_Validation(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"nothing to verify", []string{"picsum", "verify"}, "nothing to verify"},
+		{"missing checksum file", []string{"picsum", "verify", "--checksum-file", filepath.Join(dir, "missing")}, "failed to open checksum file"},
+		{"missing manifest", []string{"picsum", "verify", "--manifest", filepath.Join(dir, "missing.yaml")}, "failed to open manifest"},
+		{"no sidecar", []string{"picsum", "-q", "verify", filepath.Join(dir, "missing.jpg")}, "1 of 1 files failed verification"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildCommand().Run(context.Background(), tt.args)
+			if err == nil || !contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Package convert to re-encode a downloaded image in another format
+*/
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	_ "golang.org/x/image/webp" // decode WebP downloads, there is no WebP encoder
+)
+
+// Image formats a download can be converted to
+const (
+	PNG  = "png"
+	GIF  = "gif"
+	JPEG = "jpeg"
+)
+
+// extensions are the file extensions of the formats, the first is used for new files
+var extensions = map[string][]string{
+	PNG:  {"png"},
+	GIF:  {"gif"},
+	JPEG: {"jpg", "jpeg"},
+}
+
+// MaxQuality is the highest JPEG quality
+const MaxQuality = 100
+
+/*
+Validate checks that format is empty or a supported format and that quality
+is 0 for the encoder default or between 1 and MaxQuality, which only JPEG takes
+*/
+func Validate(format string, quality int) error {
+	if _, ok := extensions[format]; format != "" && !ok {
+		return fmt.Errorf("unsupported conversion %q, must be %s, %s or %s", format, PNG, GIF, JPEG)
+	}
+	if quality < 0 || quality > MaxQuality {
+		return fmt.Errorf("quality must be between 1 and %d, got %d", MaxQuality, quality)
+	}
+	if quality > 0 && format != JPEG {
+		return fmt.Errorf("option --quality only applies to %s", JPEG)
+	}
+	return nil
+}
+
+// FromFilename returns the format named by the extension of filename, empty if it is none of them
+func FromFilename(filename string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	for format, exts := range extensions {
+		for _, e := range exts {
+			if e == ext {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// Extension returns the file extension, without the dot, of files in format
+func Extension(format string) string {
+	return extensions[format][0]
+}
+
+/*
+Decode decodes the JPEG, PNG, GIF or WebP image read from r and returns it with
+its format. A WebP image can only be encoded in one of the other formats.
+*/
+func Decode(r io.Reader) (image.Image, string, error) {
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %v", err)
+	}
+	return img, format, nil
+}
+
+/*
+Encode encodes img in format. A quality of 0 uses the default of the JPEG
+encoder.
+*/
+func Encode(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case PNG:
+		err = png.Encode(&buf, img)
+	case GIF:
+		err = gif.Encode(&buf, img, nil)
+	case JPEG:
+		if quality == 0 {
+			quality = jpeg.DefaultQuality
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	default:
+		return nil, fmt.Errorf("unsupported conversion %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", format, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,147 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// gradient draws a small gradient
+func gradient() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := range 16 {
+		for x := range 32 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 8), G: uint8(y * 16), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		quality int
+		wantErr bool
+	}{
+		{"none", "", 0, false},
+		{"png", PNG, 0, false},
+		{"gif", GIF, 0, false},
+		{"jpeg with quality", JPEG, 60, false},
+		{"jpeg lowest quality", JPEG, 1, false},
+		{"jpeg highest quality", JPEG, 100, false},
+		{"unknown format", "bmp", 0, true},
+		{"quality too high", JPEG, 101, true},
+		{"negative quality", JPEG, -1, true},
+		{"quality with png", PNG, 80, true},
+		{"quality without format", "", 80, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.format, tt.quality)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q, %d) error = %v, wantErr %v", tt.format, tt.quality, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromFilename(t *testing.T) {
+	tests := map[string]string{
+		"hero.png":         PNG,
+		"dir/hero.GIF":     GIF,
+		"hero.jpg":         JPEG,
+		"hero.jpeg":        JPEG,
+		"hero.webp":        "",
+		"hero":             "",
+		"{id}.{ext}":       "",
+		"fixtures.png/pic": "",
+	}
+	for filename, want := range tests {
+		if got := FromFilename(filename); got != want {
+			t.Errorf("FromFilename(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestExtension(t *testing.T) {
+	if got := Extension(JPEG); got != "jpg" {
+		t.Errorf("Extension(jpeg) = %q, want jpg", got)
+	}
+	if got := Extension(PNG); got != "png" {
+		t.Errorf("Extension(png) = %q, want png", got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// GIVEN
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, gradient(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	img, format, err := Decode(&buf)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if format != JPEG || img.Bounds().Dx() != 32 || img.Bounds().Dy() != 16 {
+		t.Errorf("Decode() = %s %v, want jpeg 32x16", format, img.Bounds())
+	}
+}
+
+func TestDecode_NotAnImage(t *testing.T) {
+	_, _, err := Decode(bytes.NewReader([]byte("image bytes")))
+	if err == nil || err.Error() != "failed to decode image: image: unknown format" {
+		t.Errorf("Expected a decode error, got %v", err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, format := range []string{PNG, GIF, JPEG} {
+		t.Run(format, func(t *testing.T) {
+			// WHEN
+			data, err := Encode(gradient(), format, 0)
+
+			// THEN
+			if err != nil {
+				t.Fatalf("Encode() unexpected error: %v", err)
+			}
+			config, got, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Failed to decode the converted image: %v", err)
+			}
+			if got != format || config.Width != 32 || config.Height != 16 {
+				t.Errorf("Encode() = %s %dx%d, want %s 32x16", got, config.Width, config.Height, format)
+			}
+		})
+	}
+}
+
+func TestEncode_Quality(t *testing.T) {
+	// WHEN
+	low, err := Encode(gradient(), JPEG, 5)
+	if err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+	high, err := Encode(gradient(), JPEG, 100)
+	if err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+
+	// THEN
+	if len(low) >= len(high) {
+		t.Errorf("Expected quality 5 (%d bytes) to be smaller than quality 100 (%d bytes)", len(low), len(high))
+	}
+}
+
+func TestEncode_UnsupportedFormat(t *testing.T) {
+	if _, err := Encode(gradient(), "bmp", 0); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
@@ -4,21 +4,53 @@ Package download to download images from URLs
 package download
 
 import (
+	"context"
 	"fmt"
+	"mime"
 	"net/http"
+	"regexp"
 
 	"github.com/siakhooi/picsum/internal/console"
 	"github.com/siakhooi/picsum/internal/httpclient"
 )
 
+// imageIDHeader is the response header picsum.photos uses to report the served image
+const imageIDHeader = "Picsum-ID"
+
+// imageIDPath matches the image ID in the URL picsum.photos redirects to
+var imageIDPath = regexp.MustCompile(`/id/([^/]+)/`)
+
+// Result is a successful download, the embedded response body holds the image
+type Result struct {
+	*http.Response
+	// ImageID is the picsum.photos catalog ID of the served image, empty if unknown
+	ImageID string
+}
+
+/*
+ResolveImageID returns the catalog ID of the image served in resp, taken from
+the Picsum-ID header or, failing that, from the final redirected URL
+*/
+func ResolveImageID(resp *http.Response) string {
+	if id := resp.Header.Get(imageIDHeader); id != "" {
+		return id
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		if match := imageIDPath.FindStringSubmatch(resp.Request.URL.Path); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
 /*
 ImageWithClient downloads an image from the given URL using the provided HTTP client
 */
-func ImageWithClient(client httpclient.Getter, url string, quiet bool) (*http.Response, error) {
+func ImageWithClient(ctx context.Context, client httpclient.Getter, url string, quiet bool) (*Result, error) {
 	if !quiet {
 		console.Stdoutln("Downloading from %s...", url)
 	}
-	resp, err := client.Get(url)
+	resp, err := client.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download image: %v", err)
 	}
@@ -28,13 +60,25 @@ func ImageWithClient(client httpclient.Getter, url string, quiet bool) (*http.Re
 		return nil, fmt.Errorf("server returned status: %s", resp.Status)
 	}
 
-	return resp, nil
+	return &Result{Response: resp, ImageID: ResolveImageID(resp)}, nil
+}
+
+/*
+CheckContentType verifies that resp is of the media type want, ignoring
+parameters such as the charset
+*/
+func CheckContentType(resp *http.Response, want string) error {
+	header := resp.Header.Get("Content-Type")
+	if got, _, err := mime.ParseMediaType(header); err != nil || got != want {
+		return fmt.Errorf("server returned Content-Type %q, expected %s", header, want)
+	}
+	return nil
 }
 
 /*
-Image downloads an image from the given URL and returns the HTTP response
+Image downloads an image from the given URL and returns the download result
 Uses the default HTTP client
 */
-func Image(url string, quiet bool) (*http.Response, error) {
-	return ImageWithClient(httpclient.NewDefaultClient(), url, quiet)
+func Image(ctx context.Context, url string, quiet bool) (*Result, error) {
+	return ImageWithClient(ctx, httpclient.NewDefaultClient(), url, quiet)
 }
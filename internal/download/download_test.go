@@ -1,9 +1,11 @@
 package download
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -20,7 +22,7 @@ func TestImage_Success(t *testing.T) {
 	defer server.Close()
 
 	// WHEN
-	resp, err := Image(server.URL, false)
+	resp, err := Image(context.Background(), server.URL, false)
 
 	// THEN
 	if err != nil {
@@ -42,7 +44,7 @@ func TestImage_NonOKStatus(t *testing.T) {
 	defer server.Close()
 
 	// WHEN
-	resp, err := Image(server.URL, false)
+	resp, err := Image(context.Background(), server.URL, false)
 
 	// THEN
 	if err == nil {
@@ -63,7 +65,7 @@ func TestImage_InvalidURL(t *testing.T) {
 	invalidURL := "http://invalid-host-that-does-not-exist-12345.com"
 
 	// WHEN
-	resp, err := Image(invalidURL, false)
+	resp, err := Image(context.Background(), invalidURL, false)
 
 	// THEN
 	if err == nil {
@@ -88,7 +90,7 @@ func TestImage_ServerError(t *testing.T) {
 	defer server.Close()
 
 	// WHEN
-	resp, err := Image(server.URL, false)
+	resp, err := Image(context.Background(), server.URL, false)
 
 	// THEN
 	if err == nil {
@@ -109,7 +111,7 @@ type MockHTTPClient struct {
 	GetFunc func(url string) (*http.Response, error)
 }
 
-func (m *MockHTTPClient) Get(url string) (*http.Response, error) {
+func (m *MockHTTPClient) Get(_ context.Context, url string) (*http.Response, error) {
 	if m.GetFunc != nil {
 		return m.GetFunc(url)
 	}
@@ -128,7 +130,7 @@ func TestImageWithClient_MockSuccess(t *testing.T) {
 	}
 
 	// WHEN
-	resp, err := ImageWithClient(mockClient, "http://example.com/image.jpg", true)
+	resp, err := ImageWithClient(context.Background(), mockClient, "http://example.com/image.jpg", true)
 
 	// THEN
 	if err != nil {
@@ -150,7 +152,7 @@ func TestImageWithClient_MockError(t *testing.T) {
 	}
 
 	// WHEN
-	resp, err := ImageWithClient(mockClient, "http://example.com/image.jpg", true)
+	resp, err := ImageWithClient(context.Background(), mockClient, "http://example.com/image.jpg", true)
 
 	// THEN
 	if err == nil {
@@ -179,7 +181,7 @@ func TestImageWithClient_MockNonOKStatus(t *testing.T) {
 	}
 
 	// WHEN
-	resp, err := ImageWithClient(mockClient, "http://example.com/image.jpg", true)
+	resp, err := ImageWithClient(context.Background(), mockClient, "http://example.com/image.jpg", true)
 
 	// THEN
 	if err == nil {
@@ -206,7 +208,7 @@ func TestImageWithClient_DefaultClient(t *testing.T) {
 	client := httpclient.NewDefaultClient()
 
 	// WHEN
-	resp, err := ImageWithClient(client, server.URL, true)
+	resp, err := ImageWithClient(context.Background(), client, server.URL, true)
 
 	// THEN
 	if err != nil {
@@ -218,3 +220,126 @@ func TestImageWithClient_DefaultClient(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
 	}
 }
+
+func TestImageWithClient_ResolvesImageIDFromHeader(t *testing.T) {
+	// GIVEN
+	mockClient := &MockHTTPClient{
+		GetFunc: func(_ string) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Picsum-Id": []string{"237"}},
+				Body:       io.NopCloser(strings.NewReader("data")),
+			}, nil
+		},
+	}
+
+	// WHEN
+	result, err := ImageWithClient(context.Background(), mockClient, "http://example.com/200", true)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("ImageWithClient failed: %v", err)
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	if result.ImageID != "237" {
+		t.Errorf("Expected image ID 237, got %q", result.ImageID)
+	}
+}
+
+func TestResolveImageID(t *testing.T) {
+	redirected, _ := url.Parse("https://fastly.picsum.photos/id/1025/200/300.jpg?hmac=abc")
+	plain, _ := url.Parse("https://picsum.photos/200/300")
+
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		expected string
+	}{
+		{
+			name:     "header",
+			resp:     &http.Response{Header: http.Header{"Picsum-Id": []string{"10"}}, Request: &http.Request{URL: redirected}},
+			expected: "10",
+		},
+		{
+			name:     "redirect URL",
+			resp:     &http.Response{Header: http.Header{}, Request: &http.Request{URL: redirected}},
+			expected: "1025",
+		},
+		{
+			name:     "unknown",
+			resp:     &http.Response{Header: http.Header{}, Request: &http.Request{URL: plain}},
+			expected: "",
+		},
+		{
+			name:     "no request",
+			resp:     &http.Response{Header: http.Header{}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveImageID(tt.resp); got != tt.expected {
+				t.Errorf("ResolveImageID() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImage_ResolvesImageIDFromRedirect(t *testing.T) {
+	// GIVEN
+	mux := http.NewServeMux()
+	mux.HandleFunc("/200", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/id/42/200/200.jpg", http.StatusFound)
+	})
+	mux.HandleFunc("/id/42/200/200.jpg", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("image"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// WHEN
+	result, err := Image(context.Background(), server.URL+"/200", true)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Image failed: %v", err)
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	if result.ImageID != "42" {
+		t.Errorf("Expected image ID 42, got %q", result.ImageID)
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		wantErr     bool
+	}{
+		{"match", "image/webp", false},
+		{"match with parameters", "image/webp; charset=binary", false},
+		{"other type", "image/jpeg", true},
+		{"missing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			resp := &http.Response{Header: http.Header{}}
+			if tt.contentType != "" {
+				resp.Header.Set("Content-Type", tt.contentType)
+			}
+
+			// WHEN
+			err := CheckContentType(resp, "image/webp")
+
+			// THEN
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckContentType(%q) error = %v, wantErr %v", tt.contentType, err, tt.wantErr)
+			}
+		})
+	}
+}
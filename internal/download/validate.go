@@ -0,0 +1,39 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // register the GIF header decoder
+	_ "image/jpeg" // register the JPEG header decoder
+	_ "image/png"  // register the PNG header decoder
+	"io"
+	"net/http"
+
+	_ "golang.org/x/image/webp" // register the WebP header decoder
+)
+
+// replayBody serves the bytes already read from the header before the rest of the original body
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+/*
+CheckImage decodes the image header of resp and verifies that it is a JPEG,
+PNG, GIF or WebP image of width x height. The header bytes are replayed, so
+resp.Body still yields the whole image afterwards.
+*/
+func CheckImage(resp *http.Response, width, height int) error {
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(resp.Body, &header))
+	resp.Body = replayBody{Reader: io.MultiReader(&header, resp.Body), Closer: resp.Body}
+
+	if err != nil {
+		return fmt.Errorf("downloaded content is not an image (Content-Type %q)", resp.Header.Get("Content-Type"))
+	}
+	if config.Width != width || config.Height != height {
+		return fmt.Errorf("downloaded image is %dx%d, expected %dx%d", config.Width, config.Height, width, height)
+	}
+	return nil
+}
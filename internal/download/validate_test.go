@@ -0,0 +1,124 @@
+package download
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// pngImage encodes a blank PNG of the given size
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// webpFile builds a WebP file of a single chunk
+func webpFile(chunk string, data []byte) []byte {
+	file := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk + "\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(file[4:], uint32(12+len(data)))
+	binary.LittleEndian.PutUint32(file[16:], uint32(len(data)))
+	return append(file, data...)
+}
+
+// imageResponse wraps body in a response of the given Content-Type
+func imageResponse(contentType string, body []byte) *http.Response {
+	return &http.Response{
+		Header: http.Header{"Content-Type": {contentType}},
+		Body:   io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func TestCheckImage_ReplaysBody(t *testing.T) {
+	// GIVEN
+	body := pngImage(t, 200, 100)
+	resp := imageResponse("image/png", body)
+
+	// WHEN
+	err := CheckImage(resp, 200, 100)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("CheckImage() unexpected error: %v", err)
+	}
+	got, err := io.ReadAll(resp.Body)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Errorf("Expected the whole image to be readable after the check, got %d of %d bytes (%v)", len(got), len(body), err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		t.Errorf("Close() unexpected error: %v", err)
+	}
+}
+
+func TestCheckImage_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *http.Response
+		want string
+	}{
+		{
+			name: "html page",
+			resp: imageResponse("text/html", []byte("<html><body>Please log in to continue</body></html>")),
+			want: `downloaded content is not an image (Content-Type "text/html")`,
+		},
+		{
+			name: "empty body",
+			resp: imageResponse("image/jpeg", nil),
+			want: `downloaded content is not an image (Content-Type "image/jpeg")`,
+		},
+		{
+			name: "other size",
+			resp: imageResponse("image/png", pngImage(t, 100, 100)),
+			want: "downloaded image is 100x100, expected 200x100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckImage(tt.resp, 200, 100)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("CheckImage() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckImage_WebP(t *testing.T) {
+	vp8 := []byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(vp8[6:], 200)
+	binary.LittleEndian.PutUint16(vp8[8:], 100)
+
+	vp8l := []byte{0x2f, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(vp8l[1:], 199|99<<14)
+
+	vp8x := []byte{0, 0, 0, 0, 199, 0, 0, 99, 0, 0}
+
+	tests := []struct {
+		name    string
+		body    []byte
+		wantErr bool
+	}{
+		{"lossy", webpFile("VP8 ", vp8), false},
+		{"lossless", webpFile("VP8L", vp8l), false},
+		{"extended", webpFile("VP8X", vp8x), false},
+		{"bad start code", webpFile("VP8 ", make([]byte, 10)), true},
+		{"bad signature", webpFile("VP8L", make([]byte, 10)), true},
+		{"truncated", webpFile("VP8 ", vp8)[:24], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckImage(imageResponse("image/webp", tt.body), 200, 100)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// snapshot is the SHA-256 of the pixels of the chain in TestApply_Snapshot
+const snapshot = "d1d8d969f05d726d56dc1df083124d8f5d3a99fae3ecae2d8ade1723a6e97188"
+
+// pixel returns a 1x1 image of c
+func pixel(c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+	return img
+}
+
+// gradient draws a 64x64 image with a different color in every pixel
+func gradient() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 4), B: uint8((x + y) * 2), A: 255})
+		}
+	}
+	return img
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		length  int
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"single", "sepia", 1, false},
+		{"chain", "sepia, tint=#336699,contrast=0.8,brightness=1.2,invert", 5, false},
+		{"repeated", "invert,invert", 2, false},
+		{"unknown filter", "blur", 0, true},
+		{"value on sepia", "sepia=1", 0, true},
+		{"tint without color", "tint", 0, true},
+		{"invalid tint", "tint=blue", 0, true},
+		{"contrast without factor", "contrast", 0, true},
+		{"negative brightness", "brightness=-1", 0, true},
+		{"not a number", "contrast=high", 0, true},
+		{"nan", "contrast=NaN", 0, true},
+		{"empty item", "sepia,", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if len(chain) != tt.length {
+				t.Errorf("Parse(%q) = %d filters, want %d", tt.spec, len(chain), tt.length)
+			}
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		spec string
+		in   color.NRGBA
+		want color.NRGBA
+	}{
+		{"invert", color.NRGBA{R: 10, G: 200, B: 255, A: 128}, color.NRGBA{R: 245, G: 55, B: 0, A: 128}},
+		{"sepia", color.NRGBA{R: 100, G: 100, B: 100, A: 255}, color.NRGBA{R: 135, G: 120, B: 93, A: 255}},
+		{"sepia", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, color.NRGBA{R: 255, G: 255, B: 238, A: 255}},
+		{"brightness=1.5", color.NRGBA{R: 100, G: 200, B: 0, A: 255}, color.NRGBA{R: 150, G: 255, B: 0, A: 255}},
+		{"brightness=1", color.NRGBA{R: 1, G: 2, B: 3, A: 255}, color.NRGBA{R: 1, G: 2, B: 3, A: 255}},
+		{"contrast=0.5", color.NRGBA{R: 0, G: 128, B: 255, A: 255}, color.NRGBA{R: 64, G: 128, B: 192, A: 255}},
+		{"contrast=0", color.NRGBA{R: 0, G: 90, B: 255, A: 255}, color.NRGBA{R: 128, G: 128, B: 128, A: 255}},
+		{"tint=#336699", color.NRGBA{R: 128, G: 128, B: 128, A: 255}, color.NRGBA{R: 0x33, G: 0x66, B: 0x99, A: 255}},
+		{"tint=#336699", color.NRGBA{A: 255}, color.NRGBA{A: 255}},
+		{"tint=#336699", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"invert,brightness=0.5", color.NRGBA{R: 55, A: 255}, color.NRGBA{R: 100, G: 128, B: 128, A: 255}},
+		{"brightness=0.5,invert", color.NRGBA{R: 55, A: 255}, color.NRGBA{R: 227, G: 255, B: 255, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			chain, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.spec, err)
+			}
+			if got := chain.Apply(pixel(tt.in)).NRGBAAt(0, 0); got != tt.want {
+				t.Errorf("%s of %v = %v, want %v", tt.spec, tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_Bounds(t *testing.T) {
+	// GIVEN an image that does not start at the origin
+	img := gradient().SubImage(image.Rect(10, 20, 30, 25))
+
+	// WHEN
+	got := Chain{invert}.Apply(img)
+
+	// THEN
+	if got.Bounds() != image.Rect(0, 0, 20, 5) {
+		t.Fatalf("Apply() bounds = %v, want 20x5", got.Bounds())
+	}
+	if want := invert(gradient().NRGBAAt(10, 20)); got.NRGBAAt(0, 0) != want {
+		t.Errorf("Apply() first pixel = %v, want %v", got.NRGBAAt(0, 0), want)
+	}
+}
+
+func TestApply_Snapshot(t *testing.T) {
+	// The output of a chain is pinned, so that a change to any filter is noticed
+	chain, err := Parse("sepia,tint=#336699,contrast=0.8,brightness=1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(chain.Apply(gradient()).Pix)
+
+	if got := hex.EncodeToString(sum[:]); got != snapshot {
+		t.Errorf("Apply() pixels hash to %s, want %s", got, snapshot)
+	}
+}
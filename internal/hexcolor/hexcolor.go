@@ -0,0 +1,27 @@
+/*
+Package hexcolor to parse the #rgb and #rrggbb colors of --pad-color and --filter tint
+*/
+package hexcolor
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"strconv"
+)
+
+var pattern = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Parse parses a #rgb or #rrggbb color, the # is optional
+func Parse(s string) (color.RGBA, error) {
+	m := pattern.FindStringSubmatch(s)
+	if m == nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, must be #rgb or #rrggbb", s)
+	}
+	hex := m[1]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, _ := strconv.ParseUint(hex, 16, 32)
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
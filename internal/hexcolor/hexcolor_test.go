@@ -0,0 +1,24 @@
+package hexcolor
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]color.RGBA{
+		"#ff8000": {R: 0xff, G: 0x80, A: 255},
+		"FF8000":  {R: 0xff, G: 0x80, A: 255},
+		"#f80":    {R: 0xff, G: 0x88, A: 255},
+	}
+	for input, want := range tests {
+		if got, err := Parse(input); err != nil || got != want {
+			t.Errorf("Parse(%q) = %v (%v), want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "#ff80", "#gggggg", "red"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) expected an error", input)
+		}
+	}
+}
@@ -4,23 +4,86 @@ Package httpclient provides an abstraction layer for HTTP operations
 package httpclient
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"time"
+)
+
+// Default timeouts used by the CLI
+const (
+	DefaultTimeout        = 2 * time.Minute
+	DefaultConnectTimeout = 10 * time.Second
 )
 
 // Getter interface defines HTTP operations
 type Getter interface {
-	Get(url string) (*http.Response, error)
+	Get(ctx context.Context, url string) (*http.Response, error)
+}
+
+// HeadGetter is implemented by the Getters that can fetch the headers of a URL without its body
+type HeadGetter interface {
+	Getter
+	Head(ctx context.Context, url string) (*http.Response, error)
+}
+
+/*
+Head fetches the status and headers of url with client. A client that is
+not a HeadGetter is sent a GET whose body is closed unread.
+*/
+func Head(ctx context.Context, client Getter, url string) (*http.Response, error) {
+	if headGetter, ok := client.(HeadGetter); ok {
+		return headGetter.Head(ctx, url)
+	}
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	resp.Body = http.NoBody
+	return resp, nil
 }
 
 // DefaultClient implements Getter using the standard http package
-type DefaultClient struct{}
+type DefaultClient struct {
+	client *http.Client
+}
 
-// Get performs an HTTP GET request using http.Get
-func (c *DefaultClient) Get(url string) (*http.Response, error) {
-	return http.Get(url)
+// Get performs an HTTP GET request that is cancelled together with ctx
+func (c *DefaultClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, url)
 }
 
-// NewDefaultClient creates a new DefaultClient instance
+// Head performs an HTTP HEAD request that is cancelled together with ctx
+func (c *DefaultClient) Head(ctx context.Context, url string) (*http.Response, error) {
+	return c.do(ctx, http.MethodHead, url)
+}
+
+func (c *DefaultClient) do(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// NewDefaultClient creates a new DefaultClient instance without timeouts
 func NewDefaultClient() Getter {
-	return &DefaultClient{}
+	return &DefaultClient{client: http.DefaultClient}
+}
+
+/*
+NewClient creates a DefaultClient that gives up on a request after timeout,
+including reading the body, and on establishing a connection after
+connectTimeout. A zero duration disables the corresponding limit.
+*/
+func NewClient(timeout, connectTimeout time.Duration) Getter {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+
+	return &DefaultClient{client: &http.Client{Timeout: timeout, Transport: transport}}
 }
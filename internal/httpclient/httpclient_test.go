@@ -1,11 +1,14 @@
 package httpclient
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultClient_Get_Success(t *testing.T) {
@@ -17,7 +20,7 @@ func TestDefaultClient_Get_Success(t *testing.T) {
 	defer server.Close()
 
 	client := NewDefaultClient()
-	resp, err := client.Get(server.URL)
+	resp, err := client.Get(context.Background(), server.URL)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -37,7 +40,7 @@ func TestDefaultClient_Get_Success(t *testing.T) {
 
 func TestDefaultClient_Get_InvalidURL(t *testing.T) {
 	client := NewDefaultClient()
-	_, err := client.Get("http://invalid-domain-that-does-not-exist-12345.com")
+	_, err := client.Get(context.Background(), "http://invalid-domain-that-does-not-exist-12345.com")
 
 	if err == nil {
 		t.Error("Expected error for invalid URL, got nil")
@@ -62,7 +65,7 @@ func TestDefaultClient_Get_StatusCodes(t *testing.T) {
 			defer server.Close()
 
 			client := NewDefaultClient()
-			resp, err := client.Get(server.URL)
+			resp, err := client.Get(context.Background(), server.URL)
 
 			if err != nil {
 				t.Fatalf("Expected no error, got %v", err)
@@ -93,7 +96,7 @@ type MockClient struct {
 	GetFunc func(url string) (*http.Response, error)
 }
 
-func (m *MockClient) Get(url string) (*http.Response, error) {
+func (m *MockClient) Get(_ context.Context, url string) (*http.Response, error) {
 	if m.GetFunc != nil {
 		return m.GetFunc(url)
 	}
@@ -111,7 +114,7 @@ func TestMockClient_Usage(t *testing.T) {
 		},
 	}
 
-	resp, err := mock.Get("http://example.com")
+	resp, err := mock.Get(context.Background(), "http://example.com")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -126,3 +129,124 @@ func TestMockClient_Usage(t *testing.T) {
 		t.Errorf("Expected 'mocked response', got '%s'", string(body))
 	}
 }
+
+func TestDefaultClient_Head(t *testing.T) {
+	// GIVEN
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("image bytes"))
+	}))
+	defer server.Close()
+
+	// WHEN
+	resp, err := Head(context.Background(), NewDefaultClient(), server.URL)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = resp.Body.Close()
+	if method != http.MethodHead {
+		t.Errorf("Expected a HEAD request, got %s", method)
+	}
+	if resp.Header.Get("Content-Type") != "image/jpeg" {
+		t.Errorf("Expected the headers of the image, got %v", resp.Header)
+	}
+}
+
+func TestHead_FallsBackToGet(t *testing.T) {
+	// GIVEN
+	body := &closeRecorder{Reader: strings.NewReader("image bytes")}
+	mock := &MockClient{
+		GetFunc: func(_ string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: body}, nil
+		},
+	}
+
+	// WHEN
+	resp, err := Head(context.Background(), mock, "http://example.com")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body != http.NoBody {
+		t.Errorf("Expected a 200 response without a body, got %d %v", resp.StatusCode, resp.Body)
+	}
+	if !body.closed {
+		t.Error("Expected the GET body to be closed")
+	}
+}
+
+// closeRecorder is a response body that records whether it was closed
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDefaultClient_Get_ContextCancelled(t *testing.T) {
+	// GIVEN
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	// WHEN
+	_, err := NewDefaultClient().Get(ctx, server.URL)
+
+	// THEN
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewClient_Timeout(t *testing.T) {
+	// GIVEN
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(50*time.Millisecond, time.Second)
+
+	// WHEN
+	start := time.Now()
+	_, err := client.Get(context.Background(), server.URL)
+
+	// THEN
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected request to time out quickly, took %v", elapsed)
+	}
+}
+
+func TestNewClient_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(time.Second, time.Second).Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = resp.Body.Close()
+}
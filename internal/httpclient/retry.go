@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default retry settings used by the CLI
+const (
+	DefaultRetries      = 0
+	DefaultRetryMaxWait = 30 * time.Second
+)
+
+// baseRetryWait is the backoff before the first retry, doubled on every attempt
+const baseRetryWait = 500 * time.Millisecond
+
+// maxDuration is the longest time.Duration
+const maxDuration = time.Duration(math.MaxInt64)
+
+// maxBackoffShift stops the doubling of the backoff, about 9 hours after baseRetryWait
+const maxBackoffShift = 16
+
+// RetryingClient implements Getter by retrying transient failures of another Getter
+type RetryingClient struct {
+	inner    Getter
+	retries  int
+	maxWait  time.Duration
+	baseWait time.Duration
+	// sleep waits for d or until ctx is done, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+	// jitter picks the actual wait for a backoff, replaced in tests
+	jitter func(backoff time.Duration) time.Duration
+}
+
+/*
+NewRetryingClient wraps inner so that transport errors, 429 and 5xx responses
+are retried up to retries times with jittered exponential backoff. No single
+wait exceeds maxWait; a Retry-After header asking for longer ends the retries.
+*/
+func NewRetryingClient(inner Getter, retries int, maxWait time.Duration) Getter {
+	if retries <= 0 {
+		return inner
+	}
+	return &RetryingClient{
+		inner:    inner,
+		retries:  retries,
+		maxWait:  maxWait,
+		baseWait: baseRetryWait,
+		sleep:    sleepContext,
+		jitter:   equalJitter,
+	}
+}
+
+// Get performs an HTTP GET request, retrying transient failures
+func (c *RetryingClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	return c.retry(ctx, url, c.inner.Get)
+}
+
+// Head performs an HTTP HEAD request, retrying transient failures
+func (c *RetryingClient) Head(ctx context.Context, url string) (*http.Response, error) {
+	return c.retry(ctx, url, func(ctx context.Context, url string) (*http.Response, error) {
+		return Head(ctx, c.inner, url)
+	})
+}
+
+// retry sends the request with send until it succeeds, fails for good or the retries run out
+func (c *RetryingClient) retry(ctx context.Context, url string, send func(ctx context.Context, url string) (*http.Response, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := send(ctx, url)
+		if attempt >= c.retries || !isRetryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := c.waitBefore(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitBefore returns how long to wait before the next attempt, false when the server asks for more than maxWait
+func (c *RetryingClient) waitBefore(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if c.maxWait > 0 && retryAfter > c.maxWait {
+				return 0, false
+			}
+			return retryAfter, true
+		}
+	}
+
+	backoff := c.baseWait << min(attempt, maxBackoffShift)
+	if c.maxWait > 0 && backoff > c.maxWait {
+		backoff = c.maxWait
+	}
+	return c.jitter(backoff), true
+}
+
+// isRetryable reports whether the outcome of a GET is a transient failure worth another attempt
+func isRetryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// A cancelled or expired context will fail every further attempt too
+		return ctx.Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+/*
+parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+date. Waits too long for a time.Duration are capped at maxDuration, so they
+still exceed any maxWait.
+*/
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		if seconds > uint64(maxDuration/time.Second) {
+			return maxDuration, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+/*
+equalJitter picks a random wait between half and all of the backoff, so
+retries spread out but never come sooner than half the backoff
+*/
+func equalJitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return half + rand.N(half+1)
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
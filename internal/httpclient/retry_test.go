@@ -0,0 +1,298 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedClient returns the scripted outcomes in order and counts the calls
+type scriptedClient struct {
+	outcomes []func() (*http.Response, error)
+	calls    int
+}
+
+func (s *scriptedClient) Get(_ context.Context, _ string) (*http.Response, error) {
+	outcome := s.outcomes[s.calls]
+	s.calls++
+	return outcome()
+}
+
+func status(code int, header http.Header) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			StatusCode: code,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(http.StatusText(code))),
+		}, nil
+	}
+}
+
+func failure(err error) func() (*http.Response, error) {
+	return func() (*http.Response, error) { return nil, err }
+}
+
+// newTestRetryingClient creates a RetryingClient that records waits instead of sleeping
+func newTestRetryingClient(inner Getter, retries int, maxWait time.Duration, waits *[]time.Duration) *RetryingClient {
+	client := NewRetryingClient(inner, retries, maxWait).(*RetryingClient)
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	client.jitter = func(backoff time.Duration) time.Duration { return backoff }
+	return client
+}
+
+func TestNewRetryingClient_NoRetries(t *testing.T) {
+	inner := &scriptedClient{}
+	if client := NewRetryingClient(inner, 0, time.Second); client != inner {
+		t.Error("Expected the inner client to be returned when retries are disabled")
+	}
+}
+
+func TestRetryingClient_RetriesServerErrorsWithBackoff(t *testing.T) {
+	// GIVEN
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusServiceUnavailable, nil),
+		failure(errors.New("connection reset by peer")),
+		status(http.StatusBadGateway, nil),
+		status(http.StatusOK, nil),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 3, 10*time.Second, &waits)
+
+	// WHEN
+	resp, err := client.Get(context.Background(), "http://example.com")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	expected := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}
+	if len(waits) != len(expected) {
+		t.Fatalf("Expected waits %v, got %v", expected, waits)
+	}
+	for i := range expected {
+		if waits[i] != expected[i] {
+			t.Errorf("Expected wait %d to be %v, got %v", i, expected[i], waits[i])
+		}
+	}
+}
+
+func TestRetryingClient_RetriesHead(t *testing.T) {
+	// GIVEN
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusServiceUnavailable, nil),
+		status(http.StatusOK, nil),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 3, 10*time.Second, &waits)
+
+	// WHEN
+	resp, err := client.Head(context.Background(), "http://example.com")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Expected success after a retry, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body != http.NoBody {
+		t.Errorf("Expected a 200 response without a body, got %d", resp.StatusCode)
+	}
+	if inner.calls != 2 || len(waits) != 1 {
+		t.Errorf("Expected 2 attempts and 1 wait, got %d and %v", inner.calls, waits)
+	}
+}
+
+func TestRetryingClient_GivesUpAfterRetries(t *testing.T) {
+	// GIVEN
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusInternalServerError, nil),
+		status(http.StatusInternalServerError, nil),
+		status(http.StatusInternalServerError, nil),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 2, 10*time.Second, &waits)
+
+	// WHEN
+	resp, err := client.Get(context.Background(), "http://example.com")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Expected the last response, got error %v", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", resp.StatusCode)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", inner.calls)
+	}
+}
+
+func TestRetryingClient_DoesNotRetryClientErrors(t *testing.T) {
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusNotFound, nil),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 3, time.Second, &waits)
+
+	resp, _ := client.Get(context.Background(), "http://example.com")
+
+	if resp.StatusCode != http.StatusNotFound || inner.calls != 1 {
+		t.Errorf("Expected a single attempt returning 404, got %d after %d calls", resp.StatusCode, inner.calls)
+	}
+}
+
+func TestRetryingClient_HonoursRetryAfter(t *testing.T) {
+	// GIVEN
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"3"}}),
+		status(http.StatusOK, nil),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 1, 10*time.Second, &waits)
+
+	// WHEN
+	_, err := client.Get(context.Background(), "http://example.com")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(waits) != 1 || waits[0] != 3*time.Second {
+		t.Errorf("Expected a single 3s wait, got %v", waits)
+	}
+}
+
+func TestRetryingClient_RetryAfterBeyondMaxWait(t *testing.T) {
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}}),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 3, 10*time.Second, &waits)
+
+	resp, _ := client.Get(context.Background(), "http://example.com")
+
+	if resp.StatusCode != http.StatusTooManyRequests || len(waits) != 0 {
+		t.Errorf("Expected to give up without waiting, got status %d and waits %v", resp.StatusCode, waits)
+	}
+}
+
+func TestRetryingClient_HugeRetryAfterBeyondMaxWait(t *testing.T) {
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"99999999999999999"}}),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 3, 10*time.Second, &waits)
+
+	resp, _ := client.Get(context.Background(), "http://example.com")
+
+	if resp.StatusCode != http.StatusServiceUnavailable || len(waits) != 0 {
+		t.Errorf("Expected to give up without waiting, got status %d and waits %v", resp.StatusCode, waits)
+	}
+}
+
+func TestRetryingClient_BackoffCappedByMaxWait(t *testing.T) {
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		status(http.StatusBadGateway, nil),
+		status(http.StatusBadGateway, nil),
+		status(http.StatusOK, nil),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 2, 700*time.Millisecond, &waits)
+
+	_, _ = client.Get(context.Background(), "http://example.com")
+
+	if len(waits) != 2 || waits[0] != 500*time.Millisecond || waits[1] != 700*time.Millisecond {
+		t.Errorf("Expected waits [500ms 700ms], got %v", waits)
+	}
+}
+
+func TestRetryingClient_StopsWhenContextCancelled(t *testing.T) {
+	// GIVEN
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	inner := &scriptedClient{outcomes: []func() (*http.Response, error){
+		failure(context.Canceled),
+	}}
+	var waits []time.Duration
+	client := newTestRetryingClient(inner, 3, time.Second, &waits)
+
+	// WHEN
+	_, err := client.Get(ctx, "http://example.com")
+
+	// THEN
+	if !errors.Is(err, context.Canceled) || inner.calls != 1 {
+		t.Errorf("Expected a single cancelled attempt, got %v after %d calls", err, inner.calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"5", 5 * time.Second, true},
+		{"Thu, 01 Jan 2026 12:00:10 GMT", 10 * time.Second, true},
+		{"Thu, 01 Jan 2026 11:00:00 GMT", 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"9223372036", 9223372036 * time.Second, true},
+		{"9223372037", maxDuration, true},
+		{"99999999999999999999999", maxDuration, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryingClient_BackoffDoesNotOverflow(t *testing.T) {
+	// GIVEN a client without a maximum wait, far into its retries
+	client := newTestRetryingClient(&scriptedClient{}, 100, 0, &[]time.Duration{})
+
+	for _, attempt := range []int{maxBackoffShift, 40, 63, 64, 99} {
+		// WHEN
+		wait, ok := client.waitBefore(attempt, nil)
+
+		// THEN
+		if !ok || wait != baseRetryWait<<maxBackoffShift {
+			t.Errorf("waitBefore(%d) = %v, %v, want %v, true", attempt, wait, ok, baseRetryWait<<maxBackoffShift)
+		}
+	}
+}
+
+func TestEqualJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		got := equalJitter(time.Second)
+		if got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("equalJitter(1s) = %v, want between 500ms and 1s", got)
+		}
+	}
+}
+
+func TestSleepContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
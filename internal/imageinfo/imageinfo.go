@@ -0,0 +1,96 @@
+/*
+Package imageinfo to fetch metadata of picsum.photos images
+*/
+package imageinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// Output formats supported by Write
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Info holds the metadata picsum.photos publishes for an image
+type Info struct {
+	ID          string `json:"id"`
+	Author      string `json:"author"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	URL         string `json:"url"`
+	DownloadURL string `json:"download_url"`
+}
+
+// ValidateFormat validates the output format name
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q, must be one of %s, %s", format, FormatText, FormatJSON)
+}
+
+/*
+FetchWithClient fetches the metadata of the image selected by imageID or seed
+from baseURL using the provided HTTP client
+*/
+func FetchWithClient(ctx context.Context, client httpclient.Getter, baseURL, imageID, seed string) (*Info, error) {
+	infoURL, err := urlbuilder.BuildInfoURL(baseURL, imageID, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(ctx, infoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image info: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status: %s", resp.Status)
+	}
+
+	var info Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode image info: %v", err)
+	}
+	return &info, nil
+}
+
+/*
+Fetch fetches the metadata of the image selected by imageID or seed
+from picsum.photos. Uses the default HTTP client
+*/
+func Fetch(ctx context.Context, imageID, seed string) (*Info, error) {
+	return FetchWithClient(ctx, httpclient.NewDefaultClient(), urlbuilder.DefaultBaseURL, imageID, seed)
+}
+
+// Write prints the image metadata to w in the given format
+func Write(w io.Writer, info *Info, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	case FormatText:
+		writer := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+		_, _ = fmt.Fprintf(writer, "ID:\t%s\n", info.ID)
+		_, _ = fmt.Fprintf(writer, "Author:\t%s\n", info.Author)
+		_, _ = fmt.Fprintf(writer, "Width:\t%d\n", info.Width)
+		_, _ = fmt.Fprintf(writer, "Height:\t%d\n", info.Height)
+		_, _ = fmt.Fprintf(writer, "URL:\t%s\n", info.URL)
+		_, _ = fmt.Fprintf(writer, "Download URL:\t%s\n", info.DownloadURL)
+		return writer.Flush()
+	}
+	return ValidateFormat(format)
+}
@@ -0,0 +1,142 @@
+package imageinfo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// serverClient is an httpclient.Getter that sends every request to a test server
+type serverClient struct {
+	server *httptest.Server
+}
+
+func (c *serverClient) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server.URL+u.RequestURI(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+const infoJSON = `{"id":"237","author":"André Spieker","width":3500,"height":2095,` +
+	`"url":"https://unsplash.com/photos/8wTPqxlnKM4","download_url":"https://picsum.photos/id/237/3500/2095"}`
+
+func newInfoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/id/237/info", "/seed/hello/info":
+			_, _ = io.WriteString(w, infoJSON)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestFetchWithClient_ByID(t *testing.T) {
+	// GIVEN
+	server := newInfoServer(t)
+	defer server.Close()
+
+	// WHEN
+	info, err := FetchWithClient(context.Background(), &serverClient{server}, server.URL, "237", "")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("FetchWithClient failed: %v", err)
+	}
+	expected := Info{
+		ID:          "237",
+		Author:      "André Spieker",
+		Width:       3500,
+		Height:      2095,
+		URL:         "https://unsplash.com/photos/8wTPqxlnKM4",
+		DownloadURL: "https://picsum.photos/id/237/3500/2095",
+	}
+	if *info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *info)
+	}
+}
+
+func TestFetchWithClient_BySeed(t *testing.T) {
+	// GIVEN
+	server := newInfoServer(t)
+	defer server.Close()
+
+	// WHEN
+	info, err := FetchWithClient(context.Background(), &serverClient{server}, server.URL, "", "hello")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("FetchWithClient failed: %v", err)
+	}
+	if info.ID != "237" {
+		t.Errorf("Expected ID 237, got %q", info.ID)
+	}
+}
+
+func TestFetchWithClient_NotFound(t *testing.T) {
+	// GIVEN
+	server := newInfoServer(t)
+	defer server.Close()
+
+	// WHEN
+	_, err := FetchWithClient(context.Background(), &serverClient{server}, server.URL, "99999", "")
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "server returned status") {
+		t.Errorf("Expected server status error, got %v", err)
+	}
+}
+
+func TestFetchWithClient_MissingSelector(t *testing.T) {
+	_, err := FetchWithClient(context.Background(), &serverClient{}, urlbuilder.DefaultBaseURL, "", "")
+	if err == nil || !strings.Contains(err.Error(), "one of --id or --seed is required") {
+		t.Errorf("Expected missing selector error, got %v", err)
+	}
+}
+
+func TestWrite_Formats(t *testing.T) {
+	info := &Info{ID: "237", Author: "André Spieker", Width: 3500, Height: 2095}
+
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{FormatText, []string{"ID:           237\n", "Author:       André Spieker\n", "Height:       2095\n"}},
+		{FormatJSON, []string{`"id": "237"`, `"width": 3500`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, info, tt.format); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, &Info{}, "yaml"); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+}
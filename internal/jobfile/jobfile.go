@@ -0,0 +1,123 @@
+/*
+Package jobfile to read download jobs written one command line per line
+*/
+package jobfile
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/siakhooi/picsum/internal/console"
+)
+
+// StdinPath is the input path that reads jobs from standard input
+const StdinPath = "-"
+
+// Job is the command line of a single download
+type Job struct {
+	// Line is the 1-based line number the job was read from
+	Line int
+	// Args are the words of the line, with quotes and escapes removed
+	Args []string
+}
+
+/*
+Load reads the jobs of the file at path, or of standard input when path
+is StdinPath
+*/
+func Load(path string) ([]Job, error) {
+	if path == StdinPath {
+		return Scan(console.Scanner())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	return Scan(bufio.NewScanner(file))
+}
+
+/*
+Scan reads a job from every line of scanner. Blank lines and lines
+starting with # are skipped.
+*/
+func Scan(scanner *bufio.Scanner) ([]Job, error) {
+	var jobs []Job
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		args, err := Split(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		jobs = append(jobs, Job{Line: line, Args: args})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+	return jobs, nil
+}
+
+/*
+Split breaks line into words at unquoted whitespace the way a shell does:
+single quotes keep everything literally, double quotes keep whitespace
+and a backslash escapes the next character outside single quotes.
+*/
+func Split(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
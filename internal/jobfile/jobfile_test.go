@@ -0,0 +1,152 @@
+package jobfile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"plain words", "200 300 --id 237 -g", []string{"200", "300", "--id", "237", "-g"}},
+		{"repeated whitespace", "  200 \t 300  ", []string{"200", "300"}},
+		{"double quotes", `200 -o "my hero.jpg"`, []string{"200", "-o", "my hero.jpg"}},
+		{"single quotes keep backslashes", `200 -o 'a\b c.jpg'`, []string{"200", "-o", `a\b c.jpg`}},
+		{"escaped space", `200 -o my\ hero.jpg`, []string{"200", "-o", "my hero.jpg"}},
+		{"escaped quote in double quotes", `-o "say \"hi\".jpg"`, []string{"-o", `say "hi".jpg`}},
+		{"quoted part of a word", `--seed=a"b c"d`, []string{"--seed=ab cd"}},
+		{"empty quoted word", `-o ""`, []string{"-o", ""}},
+		{"empty line", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			got, err := Split(tt.line)
+
+			// THEN
+			if err != nil {
+				t.Fatalf("Split(%q) unexpected error: %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit_Errors(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`200 -o "hero.jpg`, `unterminated " quote`},
+		{`200 -o 'hero.jpg`, `unterminated ' quote`},
+		{`200 -o hero\`, "trailing backslash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			// WHEN
+			_, err := Split(tt.line)
+
+			// THEN
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Split(%q) error = %v, want %q", tt.line, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan_SkipsBlankLinesAndComments(t *testing.T) {
+	// GIVEN
+	input := "# hero images\n200 300 --id 237\n\n   \n  # indented comment\n400 -g\n"
+
+	// WHEN
+	jobs, err := Scan(bufio.NewScanner(strings.NewReader(input)))
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Scan() unexpected error: %v", err)
+	}
+	want := []Job{
+		{Line: 2, Args: []string{"200", "300", "--id", "237"}},
+		{Line: 6, Args: []string{"400", "-g"}},
+	}
+	if !reflect.DeepEqual(jobs, want) {
+		t.Errorf("Scan() = %+v, want %+v", jobs, want)
+	}
+}
+
+func TestScan_ReportsLineOfSplitError(t *testing.T) {
+	// GIVEN
+	input := "200\n300 -o \"open\n"
+
+	// WHEN
+	_, err := Scan(bufio.NewScanner(strings.NewReader(input)))
+
+	// THEN
+	if err == nil || err.Error() != `line 2: unterminated " quote` {
+		t.Errorf("Scan() error = %v, want line 2 error", err)
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "jobs.txt")
+	if err := os.WriteFile(path, []byte("200 300\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	jobs, err := Load(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(jobs) != 1 || !reflect.DeepEqual(jobs[0].Args, []string{"200", "300"}) {
+		t.Errorf("Load() = %+v, want one job 200 300", jobs)
+	}
+}
+
+func TestLoad_Stdin(t *testing.T) {
+	// GIVEN
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = old }()
+	_, _ = w.WriteString("100 --seed a\n")
+	_ = w.Close()
+
+	// WHEN
+	jobs, err := Load(StdinPath)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Load(-) unexpected error: %v", err)
+	}
+	if len(jobs) != 1 || !reflect.DeepEqual(jobs[0].Args, []string{"100", "--seed", "a"}) {
+		t.Errorf("Load(-) = %+v, want one job 100 --seed a", jobs)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	// WHEN
+	_, err := Load(filepath.Join(t.TempDir(), "missing.txt"))
+
+	// THEN
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to open input:") {
+		t.Errorf("Load() error = %v, want open error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+	"github.com/siakhooi/picsum/internal/checksum"
+	"github.com/siakhooi/picsum/internal/output"
+)
+
+// LockFilename is the name of the lockfile written next to the manifest
+const LockFilename = "picsum.lock"
+
+// Pin records the image a manifest entry resolved to
+type Pin struct {
+	// Request is the manifest entry the pin was resolved for, as command line arguments
+	Request string `json:"request"`
+	// ID is the picsum.photos image ID that was served, empty if the provider does not report it
+	ID string `json:"id,omitempty"`
+	// FinalURL is the URL the image was served from after redirects
+	FinalURL string `json:"final_url"`
+	// SHA256 is the hex digest of the image
+	SHA256 string `json:"sha256"`
+}
+
+// Lock pins the images of a manifest by name
+type Lock struct {
+	Images map[string]Pin `json:"images"`
+}
+
+// LockPath returns the path of the lockfile of the manifest
+func (m *Manifest) LockPath() string {
+	return filepath.Join(m.dir, LockFilename)
+}
+
+// LoadLock reads the lockfile at path, a missing file is an empty lock
+func LoadLock(path string) (*Lock, error) {
+	lock := &Lock{Images: map[string]Pin{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %v", err)
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %v", path, err)
+	}
+	if lock.Images == nil {
+		lock.Images = map[string]Pin{}
+	}
+	return lock, nil
+}
+
+/*
+Write saves the lock at path, keeping the URLs readable. The lock is written
+to a temporary file first, so an interrupted write leaves the previous lock.
+*/
+func (l *Lock) Write(path string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lockfile: %v", err)
+	}
+	// sync owns the lockfile, so it is replaced without asking and no context is needed
+	err := output.WriteFile(context.Background(), path, true, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write lockfile: %v", err)
+	}
+	return nil
+}
+
+// Request returns the image as the command line arguments that download it, recorded in its pin
+func (img Image) Request() string {
+	args := img.Args()
+	if img.ID != "" {
+		args = append(args, "--id", img.ID)
+	}
+	if img.Seed != "" {
+		args = append(args, "--seed", img.Seed)
+	}
+	if img.Gray {
+		args = append(args, "--gray")
+	}
+	if img.Blur > 0 {
+		args = append(args, "--blurlevel", strconv.Itoa(img.Blur))
+	}
+	if img.Format != "" {
+		args = append(args, "--format", img.Format)
+	}
+	return strings.Join(args, " ")
+}
+
+// Matches reports whether the pin was resolved for the current definition of img
+func (p Pin) Matches(img Image) bool {
+	return p.Request == img.Request()
+}
+
+// Apply makes opts fetch the pinned image ID instead of a random or seeded pick
+func (p Pin) Apply(opts *arguments.Options) {
+	if p.ID != "" {
+		opts.ImageID = p.ID
+		opts.Seed = ""
+	}
+}
+
+// Verify reports whether the output of img is present with the pinned checksum
+func (m *Manifest) Verify(img Image, pin Pin) bool {
+	return checksum.Check(m.Path(img), pin.SHA256) == nil
+}
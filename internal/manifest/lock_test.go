@@ -0,0 +1,191 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+)
+
+func TestLoadLock_MissingFileIsEmpty(t *testing.T) {
+	// WHEN
+	lock, err := LoadLock(filepath.Join(t.TempDir(), LockFilename))
+
+	// THEN
+	if err != nil {
+		t.Fatalf("LoadLock() unexpected error: %v", err)
+	}
+	if lock.Images == nil || len(lock.Images) != 0 {
+		t.Errorf("LoadLock() = %+v, want an empty lock", lock)
+	}
+}
+
+func TestLock_WriteAndLoad(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), LockFilename)
+	lock := &Lock{Images: map[string]Pin{
+		"hero":   {Request: "1200 400 --id 237", ID: "237", FinalURL: "https://fastly.picsum.photos/id/237/1200/400.jpg", SHA256: "aa"},
+		"avatar": {Request: "100", FinalURL: "https://placehold.co/100x100.png?text=a&b", SHA256: "bb"},
+	}}
+
+	// WHEN
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	loaded, err := LoadLock(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("LoadLock() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, lock) {
+		t.Errorf("LoadLock() = %+v, want %+v", loaded, lock)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "\"final_url\": \"https://placehold.co/100x100.png?text=a&b\"") || strings.Contains(string(data), `"id": ""`) {
+		t.Errorf("Unexpected lockfile layout:\n%s", data)
+	}
+}
+
+func TestLock_WriteReplacesExistingLock(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	path := filepath.Join(dir, LockFilename)
+	if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lock := &Lock{Images: map[string]Pin{"hero": {Request: "200", ID: "7", SHA256: "aa"}}}
+
+	// WHEN
+	err := lock.Write(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if loaded, _ := LoadLock(path); !reflect.DeepEqual(loaded, lock) {
+		t.Errorf("LoadLock() = %+v, want %+v", loaded, lock)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the lockfile to keep its permissions, got %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestLoadLock_Invalid(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), LockFilename)
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	_, err := LoadLock(path)
+
+	// THEN
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid lockfile") {
+		t.Errorf("LoadLock() error = %v, want invalid lockfile", err)
+	}
+}
+
+func TestImage_Request(t *testing.T) {
+	tests := []struct {
+		img  Image
+		want string
+	}{
+		{Image{Width: 200}, "200"},
+		{Image{Width: 200, Height: 300, ID: "237"}, "200 300 --id 237"},
+		{Image{Width: 100, Seed: "alice", Gray: true, Blur: 2}, "100 --seed alice --gray --blurlevel 2"},
+		{Image{Width: 100, Format: "webp"}, "100 --format webp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.img.Request(); got != tt.want {
+				t.Errorf("Request() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPin_Matches(t *testing.T) {
+	// GIVEN
+	img := Image{Name: "a", Width: 100, Seed: "alice", Output: "a.jpg"}
+	pin := Pin{Request: img.Request(), ID: "64"}
+
+	// THEN
+	if !pin.Matches(img) {
+		t.Error("Expected the pin to match the image it was resolved for")
+	}
+	img.Output = "b.jpg"
+	if !pin.Matches(img) {
+		t.Error("Expected the pin to match after the output moved")
+	}
+	img.Width = 200
+	if pin.Matches(img) {
+		t.Error("Expected the pin not to match after the size changed")
+	}
+}
+
+func TestPin_Apply(t *testing.T) {
+	// GIVEN
+	opts := &arguments.Options{Seed: "alice"}
+
+	// WHEN
+	Pin{ID: "64"}.Apply(opts)
+
+	// THEN
+	if opts.ImageID != "64" || opts.Seed != "" {
+		t.Errorf("Apply() = %+v, want the pinned ID instead of the seed", opts)
+	}
+
+	// WHEN the provider reported no ID
+	opts = &arguments.Options{Seed: "alice"}
+	Pin{}.Apply(opts)
+
+	// THEN
+	if opts.ImageID != "" || opts.Seed != "alice" {
+		t.Errorf("Apply() = %+v, want the options unchanged", opts)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	m := &Manifest{dir: dir}
+	if err := os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("image bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pin := Pin{SHA256: "de7030234493a8bea844dbe1d8676e68a2c1a4b014c721f0425a22b6df66faec"}
+
+	tests := []struct {
+		name string
+		img  Image
+		pin  Pin
+		want bool
+	}{
+		{"pinned checksum", Image{Output: "a.jpg"}, pin, true},
+		{"other checksum", Image{Output: "a.jpg"}, Pin{SHA256: "00"}, false},
+		{"missing file", Image{Output: "missing.jpg"}, pin, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Verify(tt.img, tt.pin); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockPath(t *testing.T) {
+	m := &Manifest{dir: "fixtures"}
+	if got := m.LockPath(); got != filepath.Join("fixtures", LockFilename) {
+		t.Errorf("LockPath() = %q, want picsum.lock next to the manifest", got)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Package manifest to describe a set of named images in a YAML or JSON file and pin them in a lockfile
+*/
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+	"github.com/siakhooi/picsum/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Image describes a single image of the manifest by the options of the
+single-image command line
+*/
+type Image struct {
+	// Name identifies the image in messages, it must be unique
+	Name string `yaml:"name"`
+	// Width is the width in pixels
+	Width int `yaml:"width"`
+	// Height is the height in pixels, a square image of Width when zero
+	Height int `yaml:"height,omitempty"`
+	// ID selects a specific image like --id
+	ID string `yaml:"id,omitempty"`
+	// Seed selects an image like --seed
+	Seed string `yaml:"seed,omitempty"`
+	// Gray converts the image to grayscale like --gray
+	Gray bool `yaml:"gray,omitempty"`
+	// Blur is the blur level 1-10 like --blurlevel, no blur when zero
+	Blur int `yaml:"blur,omitempty"`
+	// Format is the image format like --format
+	Format string `yaml:"format,omitempty"`
+	// Output is the path the image is saved to, relative to the manifest
+	Output string `yaml:"output"`
+}
+
+// Manifest is the set of images to materialise
+type Manifest struct {
+	Images []Image `yaml:"images"`
+
+	// dir is the directory relative output paths are resolved against
+	dir string
+}
+
+/*
+Load reads and validates the manifest at path. JSON manifests are read as
+the YAML they are a subset of.
+*/
+func Load(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	m := &Manifest{dir: filepath.Dir(path)}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Validate checks every image like the command line would and rejects duplicate names and outputs
+func (m *Manifest) Validate() error {
+	if len(m.Images) == 0 {
+		return fmt.Errorf("no images defined")
+	}
+
+	names := make(map[string]bool, len(m.Images))
+	outputs := make(map[string]string, len(m.Images))
+	for i, img := range m.Images {
+		if img.Name == "" {
+			return fmt.Errorf("image %d has no name", i+1)
+		}
+		if names[img.Name] {
+			return fmt.Errorf("image name %q is used more than once", img.Name)
+		}
+		names[img.Name] = true
+
+		if err := m.validateImage(img); err != nil {
+			return fmt.Errorf("image %q: %v", img.Name, err)
+		}
+		path := m.Path(img)
+		if other, ok := outputs[path]; ok {
+			return fmt.Errorf("images %q and %q are both saved as %s", other, img.Name, path)
+		}
+		outputs[path] = img.Name
+	}
+	return nil
+}
+
+func (m *Manifest) validateImage(img Image) error {
+	if img.Width <= 0 || img.Height < 0 {
+		return fmt.Errorf("width and height must be positive numbers")
+	}
+	if img.Blur < 0 {
+		return fmt.Errorf("blur level must be between 1 and 10, got %d", img.Blur)
+	}
+	if img.Output == "" {
+		return fmt.Errorf("output is required")
+	}
+	if img.Output == output.StdoutPath {
+		return fmt.Errorf("output cannot be standard output")
+	}
+	if err := arguments.ValidateArguments(img.Args()); err != nil {
+		return err
+	}
+	return arguments.ValidateOptions(m.Options(img, arguments.Options{}))
+}
+
+// Args returns the size arguments of the image
+func (img Image) Args() []string {
+	args := []string{strconv.Itoa(img.Width)}
+	if img.Height > 0 {
+		args = append(args, strconv.Itoa(img.Height))
+	}
+	return args
+}
+
+// Path returns the output path of the image, resolved against the directory of the manifest
+func (m *Manifest) Path(img Image) string {
+	if filepath.IsAbs(img.Output) {
+		return img.Output
+	}
+	return filepath.Join(m.dir, img.Output)
+}
+
+/*
+Options returns the options downloading img, starting from base which
+carries the flags shared by all images such as the timeout and cache flags
+*/
+func (m *Manifest) Options(img Image, base arguments.Options) *arguments.Options {
+	opts := base
+	opts.ImageID = img.ID
+	opts.Seed = img.Seed
+	opts.Grayscale = img.Gray
+	opts.Blur = false
+	opts.BlurLevel = img.Blur
+	opts.Format = img.Format
+	opts.OutputPath = m.Path(img)
+	opts.Count = 1
+	return &opts
+}
@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/arguments"
+)
+
+// writeManifest writes content to a manifest file called name in a temporary directory
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_YAML(t *testing.T) {
+	// GIVEN
+	path := writeManifest(t, "fixtures.yaml", `
+images:
+  - name: hero
+    width: 1200
+    height: 400
+    id: 237
+    output: fixtures/hero.jpg
+  - name: avatar
+    width: 100
+    seed: alice
+    gray: true
+    blur: 2
+    output: /tmp/avatar.jpg
+`)
+
+	// WHEN
+	m, err := Load(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	want := []Image{
+		{Name: "hero", Width: 1200, Height: 400, ID: "237", Output: "fixtures/hero.jpg"},
+		{Name: "avatar", Width: 100, Seed: "alice", Gray: true, Blur: 2, Output: "/tmp/avatar.jpg"},
+	}
+	if !reflect.DeepEqual(m.Images, want) {
+		t.Errorf("Load() images = %+v, want %+v", m.Images, want)
+	}
+	if got := m.Path(m.Images[0]); got != filepath.Join(filepath.Dir(path), "fixtures", "hero.jpg") {
+		t.Errorf("Path() = %q, want it relative to the manifest", got)
+	}
+	if got := m.Path(m.Images[1]); got != "/tmp/avatar.jpg" {
+		t.Errorf("Path() = %q, want the absolute output unchanged", got)
+	}
+}
+
+func TestLoad_JSON(t *testing.T) {
+	// GIVEN
+	path := writeManifest(t, "fixtures.json", `{"images": [{"name": "hero", "width": 200, "id": "10", "output": "hero.jpg"}]}`)
+
+	// WHEN
+	m, err := Load(path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(m.Images) != 1 || m.Images[0].ID != "10" || m.Images[0].Width != 200 {
+		t.Errorf("Load() images = %+v, want the hero image", m.Images)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"empty file", "", "no images defined"},
+		{"unknown field", "images:\n  - name: a\n    width: 1\n    output: a.jpg\n    colour: red\n", "field colour not found"},
+		{"missing name", "images:\n  - width: 1\n    output: a.jpg\n", "image 1 has no name"},
+		{"duplicate name", "images:\n  - {name: a, width: 1, output: a.jpg}\n  - {name: a, width: 1, output: b.jpg}\n", `image name "a" is used more than once`},
+		{"duplicate output", "images:\n  - {name: a, width: 1, output: a.jpg}\n  - {name: b, width: 2, output: ./a.jpg}\n", `images "a" and "b" are both saved as`},
+		{"missing width", "images:\n  - {name: a, output: a.jpg}\n", "width and height must be positive numbers"},
+		{"negative height", "images:\n  - {name: a, width: 1, height: -1, output: a.jpg}\n", "width and height must be positive numbers"},
+		{"missing output", "images:\n  - {name: a, width: 1}\n", "output is required"},
+		{"standard output", "images:\n  - {name: a, width: 1, output: '-'}\n", "output cannot be standard output"},
+		{"id and seed", "images:\n  - {name: a, width: 1, id: 1, seed: x, output: a.jpg}\n", "mutually exclusive"},
+		{"blur level", "images:\n  - {name: a, width: 1, blur: 11, output: a.jpg}\n", "blur level must be between 1 and 10"},
+		{"unsupported format", "images:\n  - {name: a, width: 1, format: png, output: a.png}\n", `unsupported format "png"`},
+		{"negative blur level", "images:\n  - {name: a, width: 1, blur: -1, output: a.jpg}\n", "blur level must be between 1 and 10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			path := writeManifest(t, "fixtures.yaml", tt.content)
+
+			// WHEN
+			_, err := Load(path)
+
+			// THEN
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	// WHEN
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+
+	// THEN
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to open manifest:") {
+		t.Errorf("Load() error = %v, want open error", err)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	if got := (Image{Width: 200}).Args(); !reflect.DeepEqual(got, []string{"200"}) {
+		t.Errorf("Args() = %v, want [200]", got)
+	}
+	if got := (Image{Width: 200, Height: 300}).Args(); !reflect.DeepEqual(got, []string{"200", "300"}) {
+		t.Errorf("Args() = %v, want [200 300]", got)
+	}
+}
+
+func TestOptions_MapsImageOntoBase(t *testing.T) {
+	// GIVEN
+	m := &Manifest{dir: "fixtures"}
+	img := Image{Name: "a", Width: 1, Seed: "s", Gray: true, Blur: 3, Format: "webp", Output: "a.webp"}
+	base := arguments.Options{Provider: "local", NoCache: true, Quiet: true, Blur: true, Count: 5}
+
+	// WHEN
+	opts := m.Options(img, base)
+
+	// THEN
+	want := &arguments.Options{
+		Seed: "s", Grayscale: true, BlurLevel: 3, Format: "webp", OutputPath: filepath.Join("fixtures", "a.webp"), Count: 1,
+		Provider: "local", NoCache: true, Quiet: true,
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("Options() = %+v, want %+v", opts, want)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Package nametemplate to expand output filename templates with placeholders
+*/
+package nametemplate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Placeholder names supported in templates
+const (
+	ID     = "id"
+	Seed   = "seed"
+	Width  = "width"
+	Height = "height"
+	Gray   = "gray"
+	Blur   = "blur"
+	Author = "author"
+	Date   = "date"
+	N      = "n"
+	Ext    = "ext"
+)
+
+// dateLayout is the format of the {date} placeholder
+const dateLayout = "2006-01-02"
+
+// unknown is used for {id} and {author} when the value could not be resolved
+const unknown = "unknown"
+
+// placeholder matches a {name} placeholder
+var placeholder = regexp.MustCompile(`\{(\w*)\}`)
+
+// names lists every supported placeholder
+var names = []string{ID, Seed, Width, Height, Gray, Blur, Author, Date, N, Ext}
+
+// Values holds the data placeholders are replaced with
+type Values struct {
+	ID        string
+	Seed      string
+	Width     int
+	Height    int
+	Grayscale bool
+	Blur      bool
+	BlurLevel int
+	Author    string
+	Date      time.Time
+	N         int
+	Ext       string
+}
+
+// Validate checks that the template only uses supported placeholders
+func Validate(template string) error {
+	for _, match := range placeholder.FindAllStringSubmatch(template, -1) {
+		if !isSupported(match[1]) {
+			return fmt.Errorf("unknown placeholder %s in name template, supported: {%s}", match[0], strings.Join(names, "}, {"))
+		}
+	}
+	return nil
+}
+
+// Uses reports whether the template contains the named placeholder
+func Uses(template, name string) bool {
+	return strings.Contains(template, "{"+name+"}")
+}
+
+/*
+Expand replaces every placeholder in the template with its value. The values
+of {id}, {seed} and {author} come from the user or the server and are passed
+through Sanitize, so they can never add a directory to the path.
+*/
+func Expand(template string, values Values) string {
+	return placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		switch strings.Trim(match, "{}") {
+		case ID:
+			return Sanitize(orUnknown(values.ID))
+		case Seed:
+			return Sanitize(values.Seed)
+		case Width:
+			return strconv.Itoa(values.Width)
+		case Height:
+			return strconv.Itoa(values.Height)
+		case Gray:
+			if values.Grayscale {
+				return "gray"
+			}
+			return ""
+		case Blur:
+			return blurValue(values)
+		case Author:
+			return Sanitize(orUnknown(values.Author))
+		case Date:
+			return values.Date.Format(dateLayout)
+		case N:
+			return strconv.Itoa(values.N)
+		case Ext:
+			return values.Ext
+		}
+		return match
+	})
+}
+
+// Sanitize makes value safe as part of a filename by replacing path separators and a leading .. with _
+func Sanitize(value string) string {
+	value = strings.NewReplacer("/", "_", "\\", "_").Replace(value)
+	if rest, ok := strings.CutPrefix(value, ".."); ok {
+		value = "_" + rest
+	}
+	return value
+}
+
+func blurValue(values Values) string {
+	if values.BlurLevel > 0 {
+		return fmt.Sprintf("blur%d", values.BlurLevel)
+	}
+	if values.Blur {
+		return "blur"
+	}
+	return ""
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return unknown
+	}
+	return value
+}
+
+func isSupported(name string) bool {
+	for _, supported := range names {
+		if name == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package nametemplate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	values := Values{
+		ID:        "237",
+		Seed:      "hello",
+		Width:     200,
+		Height:    300,
+		Grayscale: true,
+		BlurLevel: 5,
+		Author:    "Jane Doe",
+		Date:      time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC),
+		N:         7,
+		Ext:       "jpg",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		values   Values
+		expected string
+	}{
+		{"all placeholders", "{date}/{seed}/{id}_{width}x{height}_{gray}_{blur}_{n}.{ext}", values, "2026-03-04/hello/237_200x300_gray_blur5_7.jpg"},
+		{"author", "credits/{author}.{ext}", values, "credits/Jane Doe.jpg"},
+		{"plain blur", "{blur}", Values{Blur: true}, "blur"},
+		{"no effects", "{gray}{blur}", Values{}, ""},
+		{"unknown id and author", "{id}-{author}", Values{}, "unknown-unknown"},
+		{"literal text", "hero.jpg", values, "hero.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expand(tt.template, tt.values); got != tt.expected {
+				t.Errorf("Expand() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{"{id}_{width}x{height}.{ext}", false},
+		{"plain.jpg", false},
+		{"{size}.jpg", true},
+		{"{ID}.jpg", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			err := Validate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUses(t *testing.T) {
+	if !Uses("{author}/{id}.jpg", Author) {
+		t.Error("Expected template to use {author}")
+	}
+	if Uses("{id}.jpg", Author) {
+		t.Error("Expected template not to use {author}")
+	}
+}
+
+func TestExpand_HostileValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		values   Values
+		expected string
+	}{
+		{"author climbing out", "credits/{author}.jpg", Values{Author: "../../x"}, "credits/__.._x.jpg"},
+		{"author with a directory", "credits/{author}.jpg", Values{Author: "a/b"}, "credits/a_b.jpg"},
+		{"author with a backslash", "credits/{author}.jpg", Values{Author: `..\..\x`}, "credits/__.._x.jpg"},
+		{"author of two dots", "credits/{author}/img.jpg", Values{Author: ".."}, "credits/_/img.jpg"},
+		{"absolute seed", "{seed}.jpg", Values{Seed: "/etc/passwd"}, "_etc_passwd.jpg"},
+		{"id with a directory", "{id}.jpg", Values{ID: "../237"}, "__237.jpg"},
+		{"dots inside a name are kept", "{author}.jpg", Values{Author: "J. R. R."}, "J. R. R..jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expand(tt.template, tt.values); got != tt.expected {
+				t.Errorf("Expand(%q) = %q, want %q", tt.template, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"Jane Doe": "Jane Doe",
+		"a/b":      "a_b",
+		`a\b`:      "a_b",
+		"..":       "_",
+		"...":      "_.",
+		"..hidden": "_hidden",
+		"a..b":     "a..b",
+	}
+	for value, want := range tests {
+		if got := Sanitize(value); got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -4,24 +4,100 @@ Package output to save pic file
 package output
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/siakhooi/picsum/internal/console"
 )
 
+// StdoutPath is the output path that streams the image to standard output
+const StdoutPath = "-"
+
+// filePerm is the permission of saved images
+const filePerm = 0644
+
+// promptMu serialises overwrite prompts when images are saved concurrently
+var promptMu sync.Mutex
+
+// answer is a line typed at the overwrite prompt, or the error that ended the input
+type answer struct {
+	line string
+	err  error
+}
+
+// input is the standard input the answers are read from, guarded by promptMu
+var input struct {
+	file    *os.File
+	answers <-chan answer
+}
+
+/*
+stdinAnswers returns the lines of standard input. A single goroutine reads
+them for as long as the input lasts, so a cancelled prompt leaves no read of
+its own behind and the next prompt gets the next line. Must hold promptMu.
+*/
+func stdinAnswers() <-chan answer {
+	if input.file != os.Stdin {
+		input.file = os.Stdin
+		input.answers = readAnswers(input.file)
+	}
+	return input.answers
+}
+
+// readAnswers sends every line of r to the returned channel, which is closed at the end of r
+func readAnswers(r io.Reader) <-chan answer {
+	answers := make(chan answer)
+	go func() {
+		defer close(answers)
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadString('\n')
+			if err == io.EOF {
+				answers <- answer{line: line}
+				return
+			}
+			answers <- answer{line: line, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return answers
+}
+
 /*
 promptForOverwrite asks the user for confirmation to overwrite a file.
-Returns true if the user confirms, false otherwise.
+Returns true if the user confirms, false otherwise. The prompt is abandoned
+with the error of ctx once ctx is cancelled.
 */
-func promptForOverwrite(filename string) (bool, error) {
+func promptForOverwrite(ctx context.Context, filename string) (bool, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	console.Stdout("File '%s' already exists. Overwrite? [y/N]: ", filename)
-	response, err := console.ReadLine()
-	if err != nil {
-		return false, fmt.Errorf("failed to read user input: %v", err)
+	var response string
+	select {
+	case <-ctx.Done():
+		console.Stdoutln("")
+		return false, ctx.Err()
+	case a := <-stdinAnswers():
+		if a.err != nil {
+			return false, fmt.Errorf("failed to read user input: %v", a.err)
+		}
+		response = a.line
 	}
 	response = strings.TrimSpace(strings.ToLower(response))
 	return response == "y" || response == "yes", nil
@@ -30,12 +106,40 @@ func promptForOverwrite(filename string) (bool, error) {
 /*
 SaveImage saves the HTTP response body to a file with the given filename
 */
-func SaveImage(resp *http.Response, filename string, quiet bool, force bool) error {
+func SaveImage(ctx context.Context, resp *http.Response, filename string, quiet bool, force bool) error {
+	if filename == StdoutPath {
+		return streamImage(resp)
+	}
+
+	err := WriteFile(ctx, filename, force, func(w io.Writer) error {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return fmt.Errorf("failed to save image: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		console.Stdoutln("Image saved as %s", filename)
+	}
+	return nil
+}
+
+/*
+WriteFile writes filename with write, asking before an existing file is
+replaced unless force is set. The content goes to a temporary file in the
+destination directory that is renamed over filename only once write has
+succeeded, so a failed or cancelled write never leaves a partial file.
+An existing file keeps its permissions, a new one gets filePerm less the umask.
+*/
+func WriteFile(ctx context.Context, filename string, force bool, write func(w io.Writer) error) error {
 	// Check if file exists
 	if _, err := os.Stat(filename); err == nil {
 		// File exists
 		if !force {
-			shouldOverwrite, err := promptForOverwrite(filename)
+			shouldOverwrite, err := promptForOverwrite(ctx, filename)
 			if err != nil {
 				return err
 			}
@@ -45,19 +149,60 @@ func SaveImage(resp *http.Response, filename string, quiet bool, force bool) err
 		}
 	}
 
-	file, err := os.Create(filename)
+	file, err := createTemp(filepath.Dir(filename), filepath.Base(filename))
 	if err != nil {
 		return fmt.Errorf("failed to create file: %v", err)
 	}
-	defer func() { _ = file.Close() }()
+	tmpName := file.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = os.Remove(tmpName)
+		}
+	}()
 
-	_, err = io.Copy(file, resp.Body)
+	err = write(file)
+	closeErr := file.Close()
 	if err != nil {
-		return fmt.Errorf("failed to save image: %v", err)
+		return err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write %s: %v", filename, closeErr)
 	}
 
-	if !quiet {
-		console.Stdoutln("Image saved as %s", filename)
+	if info, err := os.Stat(filename); err == nil {
+		if err := os.Chmod(tmpName, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %v", filename, err)
+		}
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filename, err)
+	}
+	committed = true
+	return nil
+}
+
+/*
+createTemp creates a new hidden temporary file next to base in dir. Unlike
+os.CreateTemp it creates the file with filePerm, so the umask applies.
+*/
+func createTemp(dir, base string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, filePerm)
+		if !os.IsExist(err) {
+			return file, err
+		}
+	}
+	return nil, fmt.Errorf("no unused temporary file name for %s in %s", base, dir)
+}
+
+/*
+streamImage writes the HTTP response body to standard output
+*/
+func streamImage(resp *http.Response) error {
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		return fmt.Errorf("failed to save image: %v", err)
 	}
 	return nil
 }
@@ -2,12 +2,16 @@ package output
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSaveImage_Success(t *testing.T) {
@@ -21,7 +25,7 @@ func TestSaveImage_Success(t *testing.T) {
 	}
 
 	// WHEN
-	err := SaveImage(resp, tmpfile, false, false)
+	err := SaveImage(context.Background(), resp, tmpfile, false, false)
 	if err != nil {
 		t.Fatalf("SaveImage failed: %v", err)
 	}
@@ -47,7 +51,7 @@ func TestSaveImage_InvalidPath(t *testing.T) {
 	}
 
 	// WHEN
-	err := SaveImage(resp, invalidPath, false, false)
+	err := SaveImage(context.Background(), resp, invalidPath, false, false)
 	// THEN
 	if err == nil {
 		t.Error("Expected error for invalid path, got nil")
@@ -64,7 +68,7 @@ func TestSaveImage_EmptyResponse(t *testing.T) {
 	}
 
 	// WHEN
-	err := SaveImage(resp, tmpfile, false, false)
+	err := SaveImage(context.Background(), resp, tmpfile, false, false)
 	if err != nil {
 		t.Fatalf("SaveImage failed: %v", err)
 	}
@@ -101,7 +105,7 @@ func TestSaveImage_CopyError(t *testing.T) {
 	}
 
 	// WHEN
-	err := SaveImage(resp, tmpfile, false, false)
+	err := SaveImage(context.Background(), resp, tmpfile, false, false)
 	if err == nil {
 		t.Error("Expected error from io.Copy failure, got nil")
 	}
@@ -131,7 +135,7 @@ func TestPromptForOverwrite_UserConfirmsWithY(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	_ = wOut.Close()
 	var buf bytes.Buffer
@@ -163,7 +167,7 @@ func TestPromptForOverwrite_UserConfirmsWithYes(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -187,7 +191,7 @@ func TestPromptForOverwrite_UserConfirmsWithUppercase(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -211,7 +215,7 @@ func TestPromptForOverwrite_UserDeclinesWithN(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -235,7 +239,7 @@ func TestPromptForOverwrite_UserDeclinesWithNo(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -259,7 +263,7 @@ func TestPromptForOverwrite_UserDeclinesWithEmptyInput(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -283,7 +287,7 @@ func TestPromptForOverwrite_UserEntersInvalidInput(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -307,7 +311,7 @@ func TestPromptForOverwrite_UserEntersYesWithWhitespace(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	if err != nil {
@@ -333,7 +337,7 @@ func TestPromptForOverwrite_ReadError(t *testing.T) {
 	_ = w.Close()
 
 	// WHEN
-	result, err := promptForOverwrite(filename)
+	result, err := promptForOverwrite(context.Background(), filename)
 
 	// THEN
 	// Should get an error because reading from closed pipe returns error
@@ -348,6 +352,70 @@ func TestPromptForOverwrite_ReadError(t *testing.T) {
 	}
 }
 
+func TestPromptForOverwrite_CancelledWhileWaiting(t *testing.T) {
+	// GIVEN a user who never answers
+	oldStdin := os.Stdin
+	oldStdout := os.Stdout
+	defer func() {
+		os.Stdin = oldStdin
+		os.Stdout = oldStdout
+	}()
+
+	r, w, _ := os.Pipe()
+	defer func() { _ = w.Close() }()
+	os.Stdin = r
+	_, wOut, _ := os.Pipe()
+	defer func() { _ = wOut.Close() }()
+	os.Stdout = wOut
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	// WHEN
+	result, err := promptForOverwrite(ctx, "test.jpg")
+
+	// THEN
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if result {
+		t.Error("Expected false when the prompt is cancelled")
+	}
+
+	// WHEN the user answers the next prompt
+	_, _ = w.Write([]byte("y\n"))
+	result, err = promptForOverwrite(context.Background(), "test.jpg")
+
+	// THEN the answer is not lost to the cancelled prompt
+	if err != nil || !result {
+		t.Errorf("Expected the next prompt to get the answer, got %v, %v", result, err)
+	}
+}
+
+func TestSaveImage_CancelledBeforePrompt(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "existing.jpg")
+	if err := os.WriteFile(filename, []byte("existing content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("new content"))}
+
+	// WHEN
+	err := SaveImage(ctx, resp, filename, true, false)
+
+	// THEN
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "existing content" {
+		t.Errorf("Expected existing file to be untouched, got %q", string(data))
+	}
+}
+
 func TestSaveImage_FileExistsAndUserDeclines(t *testing.T) {
 	// GIVEN
 	tmpfile := "test_decline.jpg"
@@ -372,7 +440,7 @@ func TestSaveImage_FileExistsAndUserDeclines(t *testing.T) {
 	}
 
 	// WHEN
-	err = SaveImage(resp, tmpfile, true, false)
+	err = SaveImage(context.Background(), resp, tmpfile, true, false)
 
 	// THEN
 	if err == nil {
@@ -415,7 +483,7 @@ func TestSaveImage_FileExistsAndPromptError(t *testing.T) {
 	}
 
 	// WHEN
-	err = SaveImage(resp, tmpfile, true, false)
+	err = SaveImage(context.Background(), resp, tmpfile, true, false)
 
 	// THEN
 	if err == nil {
@@ -425,3 +493,187 @@ func TestSaveImage_FileExistsAndPromptError(t *testing.T) {
 		t.Errorf("Expected error message to contain 'failed to read user input', got: %v", err)
 	}
 }
+
+func TestSaveImage_Stdout(t *testing.T) {
+	// GIVEN
+	oldStdout := os.Stdout
+	defer func() { os.Stdout = oldStdout }()
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	imageData := "streamed image data"
+	resp := &http.Response{
+		Body: io.NopCloser(strings.NewReader(imageData)),
+	}
+
+	// WHEN
+	err := SaveImage(context.Background(), resp, StdoutPath, false, false)
+
+	_ = w.Close()
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("SaveImage failed: %v", err)
+	}
+	if buf.String() != imageData {
+		t.Errorf("Expected only the image on stdout, got %q", buf.String())
+	}
+	if _, err := os.Stat(StdoutPath); !os.IsNotExist(err) {
+		_ = os.Remove(StdoutPath)
+		t.Error("Expected no file named '-' to be created")
+	}
+}
+
+func TestSaveImage_StdoutCopyError(t *testing.T) {
+	resp := &http.Response{
+		Body: &errorReader{},
+	}
+
+	err := SaveImage(context.Background(), resp, StdoutPath, true, false)
+
+	if err == nil || !strings.Contains(err.Error(), "failed to save image") {
+		t.Errorf("Expected error message to contain 'failed to save image', got: %v", err)
+	}
+}
+
+func TestSaveImage_CopyErrorKeepsExistingFile(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "existing.jpg")
+	if err := os.WriteFile(filename, []byte("existing content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	resp := &http.Response{
+		Body: io.NopCloser(io.MultiReader(strings.NewReader("partial"), &errorReader{})),
+	}
+
+	// WHEN
+	err := SaveImage(context.Background(), resp, filename, true, true)
+
+	// THEN
+	if err == nil {
+		t.Fatal("Expected error from io.Copy failure, got nil")
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "existing content" {
+		t.Errorf("Expected existing file to be untouched, got %q", string(data))
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestSaveImage_ReplacesExistingFileAtomically(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "existing.jpg")
+	if err := os.WriteFile(filename, []byte("existing content"), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	resp := &http.Response{
+		Body: io.NopCloser(strings.NewReader("new content")),
+	}
+
+	// WHEN
+	err := SaveImage(context.Background(), resp, filename, true, true)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("SaveImage failed: %v", err)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "new content" {
+		t.Errorf("Expected file to be replaced, got %q", string(data))
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestSaveImage_KeepsModeOfExistingFile(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "existing.jpg")
+	if err := os.WriteFile(filename, []byte("existing content"), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Chmod(filename, 0600); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+	want, _ := os.Stat(filename)
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("new content"))}
+
+	// WHEN
+	err := SaveImage(context.Background(), resp, filename, true, true)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("SaveImage failed: %v", err)
+	}
+	got, _ := os.Stat(filename)
+	if got.Mode() != want.Mode() {
+		t.Errorf("Expected mode %v to be kept, got %v", want.Mode(), got.Mode())
+	}
+}
+
+func TestSaveImage_NewFileRespectsUmask(t *testing.T) {
+	// GIVEN a file created the way os.Create would, with the umask applied
+	dir := t.TempDir()
+	probe := filepath.Join(dir, "probe")
+	if err := os.WriteFile(probe, nil, filePerm); err != nil {
+		t.Fatalf("Failed to create probe file: %v", err)
+	}
+	want, _ := os.Stat(probe)
+	filename := filepath.Join(dir, "new.jpg")
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("content"))}
+
+	// WHEN
+	err := SaveImage(context.Background(), resp, filename, true, true)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("SaveImage failed: %v", err)
+	}
+	got, _ := os.Stat(filename)
+	if got.Mode() != want.Mode() {
+		t.Errorf("Expected mode %v, got %v", want.Mode(), got.Mode())
+	}
+}
+
+func TestSaveImage_CancelledRequestCleansUp(t *testing.T) {
+	// GIVEN a server that sends part of the image and then stalls
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "cancelled.jpg")
+
+	// WHEN the request is cancelled while the body is being saved
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err = SaveImage(context.Background(), resp, filename, true, true)
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("Expected cancelled error, got %v", err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Error("Expected no image to be written")
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("Expected temporary files to be removed, found %v", matches)
+	}
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/siakhooi/picsum/internal/catalog"
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/synth"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// picsumProvider builds picsum.photos URLs, relative to its base URL
+type picsumProvider struct {
+	name    string
+	baseURL string
+}
+
+// NewPicsum creates a provider of picsum.photos URLs under baseURL selected by name
+func NewPicsum(name, baseURL string) Provider {
+	return &picsumProvider{name: name, baseURL: baseURL}
+}
+
+func (p *picsumProvider) Name() string {
+	return p.name
+}
+
+func (p *picsumProvider) BuildURL(req Request) (string, error) {
+	if p.name == Local && req.Format == urlbuilder.FormatWebP {
+		return "", fmt.Errorf("provider %s can only generate %s images", Local, urlbuilder.FormatJPG)
+	}
+	imageURL, _, err := urlbuilder.BuildURL(p.baseURL, req.Args, req.ImageID, req.Seed, req.Grayscale, req.Blur, req.BlurLevel, req.Format)
+	return imageURL, err
+}
+
+func (p *picsumProvider) BuildFilename(req Request) (string, error) {
+	_, filename, err := urlbuilder.BuildURL(p.baseURL, req.Args, req.ImageID, req.Seed, req.Grayscale, req.Blur, req.BlurLevel, req.Format)
+	return filename, err
+}
+
+func (p *picsumProvider) List(ctx context.Context, client httpclient.Getter, page, limit int) ([]imageinfo.Info, error) {
+	return catalog.ListWithClient(ctx, client, p.baseURL, page, limit)
+}
+
+func (p *picsumProvider) Info(ctx context.Context, client httpclient.Getter, imageID, seed string) (*imageinfo.Info, error) {
+	return imageinfo.FetchWithClient(ctx, client, p.baseURL, imageID, seed)
+}
+
+// NumberedURL adds random=n, so picsum.photos and any cache in between serve distinct random images
+func (p *picsumProvider) NumberedURL(imageURL string, n int) string {
+	return urlbuilder.NumberedURL(imageURL, n)
+}
+
+// Client generates the images of the local provider, picsum fetches them over HTTP
+func (p *picsumProvider) Client() httpclient.Getter {
+	if p.name == Local {
+		return synth.NewClient(p.baseURL)
+	}
+	return nil
+}
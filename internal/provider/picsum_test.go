@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+func TestPicsum_BuildURLAndFilename(t *testing.T) {
+	p := NewPicsum(Picsum, urlbuilder.DefaultBaseURL)
+	req := Request{Args: []string{"200", "300"}, Seed: "hello", Grayscale: true, BlurLevel: 2}
+
+	imageURL, err := p.BuildURL(req)
+	if err != nil {
+		t.Fatalf("BuildURL failed: %v", err)
+	}
+	filename, err := p.BuildFilename(req)
+	if err != nil {
+		t.Fatalf("BuildFilename failed: %v", err)
+	}
+
+	if imageURL != "https://picsum.photos/seed/hello/200/300?grayscale&blur=2" {
+		t.Errorf("Unexpected URL %q", imageURL)
+	}
+	if filename != "seed_hello_200x300_gray_blur2.jpg" {
+		t.Errorf("Unexpected filename %q", filename)
+	}
+}
+
+func TestPicsum_BaseURL(t *testing.T) {
+	imageURL, err := NewPicsum(Picsum, "http://localhost:8080/picsum").BuildURL(Request{Args: []string{"200"}, ImageID: "237"})
+	if err != nil {
+		t.Fatalf("BuildURL failed: %v", err)
+	}
+	if imageURL != "http://localhost:8080/picsum/id/237/200" {
+		t.Errorf("Unexpected URL %q", imageURL)
+	}
+}
+
+func TestPicsum_InvalidSize(t *testing.T) {
+	p := NewPicsum(Picsum, urlbuilder.DefaultBaseURL)
+	if _, err := p.BuildURL(Request{Args: []string{"abc"}}); err == nil {
+		t.Error("Expected error for an invalid size")
+	}
+	if _, err := p.BuildFilename(Request{Args: []string{"abc"}}); err == nil {
+		t.Error("Expected error for an invalid size")
+	}
+}
+
+func TestPicsum_Format(t *testing.T) {
+	req := Request{Args: []string{"200"}, ImageID: "237", Format: "webp"}
+
+	imageURL, err := NewPicsum(Picsum, urlbuilder.DefaultBaseURL).BuildURL(req)
+	if err != nil || imageURL != "https://picsum.photos/id/237/200.webp" {
+		t.Errorf("Unexpected URL %q, error %v", imageURL, err)
+	}
+	filename, err := NewPicsum(Picsum, urlbuilder.DefaultBaseURL).BuildFilename(req)
+	if err != nil || filename != "id_237_200.webp" {
+		t.Errorf("Unexpected filename %q, error %v", filename, err)
+	}
+}
+
+func TestLocal_RejectsWebP(t *testing.T) {
+	_, err := NewPicsum(Local, urlbuilder.DefaultBaseURL).BuildURL(Request{Args: []string{"200"}, Format: "webp"})
+	if err == nil || err.Error() != "provider local can only generate jpg images" {
+		t.Errorf("Expected webp to be rejected, got %v", err)
+	}
+	if _, err := NewPicsum(Local, urlbuilder.DefaultBaseURL).BuildURL(Request{Args: []string{"200"}, Format: "jpg"}); err != nil {
+		t.Errorf("Expected jpg to be accepted, got %v", err)
+	}
+}
+
+func TestPicsum_NumberedURL(t *testing.T) {
+	if got := NewPicsum(Picsum, urlbuilder.DefaultBaseURL).NumberedURL("https://picsum.photos/200?grayscale", 3); got != "https://picsum.photos/200?grayscale&random=3" {
+		t.Errorf("NumberedURL() = %q", got)
+	}
+}
+
+func TestPicsum_Client(t *testing.T) {
+	if client := NewPicsum(Picsum, urlbuilder.DefaultBaseURL).Client(); client != nil {
+		t.Errorf("Expected picsum to fetch over HTTP, got %T", client)
+	}
+	if client := NewPicsum(Local, urlbuilder.DefaultBaseURL).Client(); client == nil {
+		t.Error("Expected local to supply the client that generates its images")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Package provider to build image requests for picsum.photos and other placeholder image services
+*/
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// Built-in providers
+const (
+	// Picsum fetches images from picsum.photos or the --base-url mirror
+	Picsum = "picsum"
+	// Local generates images in the picsum.photos URL scheme without a network
+	Local = "local"
+)
+
+/*
+Request selects an image. Every provider is driven by the options of the
+picsum.photos URL scheme and rejects the ones it cannot honour.
+*/
+type Request = urlbuilder.Route
+
+// Provider turns image requests into URLs and filenames and describes its images
+type Provider interface {
+	// Name returns the name the provider is selected by
+	Name() string
+	// BuildURL returns the URL of the requested image
+	BuildURL(req Request) (string, error)
+	// BuildFilename returns the default filename of the requested image
+	BuildFilename(req Request) (string, error)
+	// List fetches a page of the images the provider offers
+	List(ctx context.Context, client httpclient.Getter, page, limit int) ([]imageinfo.Info, error)
+	// Info fetches the metadata of the image selected by imageID or seed
+	Info(ctx context.Context, client httpclient.Getter, imageID, seed string) (*imageinfo.Info, error)
+	// NumberedURL returns the URL of the n-th image of a batch requested by imageURL
+	NumberedURL(imageURL string, n int) string
+	// Client returns the Getter that generates the images without a network, nil to fetch them over HTTP
+	Client() httpclient.Getter
+}
+
+// DefaultConfigPath returns providers.json in the picsum user configuration directory
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate configuration directory: %v", err)
+	}
+	return filepath.Join(dir, "picsum", "providers.json"), nil
+}
+
+/*
+Lookup returns the built-in provider called name, serving the URLs under
+baseURL, or the templated provider of that name defined in the configuration
+file at configPath, which defaults to DefaultConfigPath. An empty name
+selects picsum.
+*/
+func Lookup(name, baseURL, configPath string) (Provider, error) {
+	switch name {
+	case "", Picsum:
+		return NewPicsum(Picsum, baseURL), nil
+	case Local:
+		return NewPicsum(Local, baseURL), nil
+	}
+
+	if configPath == "" {
+		var err error
+		if configPath, err = DefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	var configs map[string]TemplateConfig
+	if _, err := os.Stat(configPath); !errors.Is(err, os.ErrNotExist) {
+		if configs, err = LoadConfig(configPath); err != nil {
+			return nil, err
+		}
+	}
+	config, ok := configs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, must be one of %s", name, strings.Join(names(configs), ", "))
+	}
+	return NewTemplate(name, config)
+}
+
+// names returns the built-in and configured provider names in order
+func names(configs map[string]TemplateConfig) []string {
+	configured := make([]string, 0, len(configs))
+	for name := range configs {
+		configured = append(configured, name)
+	}
+	sort.Strings(configured)
+	return append([]string{Picsum, Local}, configured...)
+}
+
+/*
+ListAll pages through the images of p starting at page until a page
+shorter than limit is returned
+*/
+func ListAll(ctx context.Context, p Provider, client httpclient.Getter, page, limit int) ([]imageinfo.Info, error) {
+	var all []imageinfo.Info
+	for {
+		images, err := p.List(ctx, client, page, limit)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, images...)
+		if len(images) < limit {
+			return all, nil
+		}
+		page++
+	}
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// writeConfig writes a provider configuration file and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "providers.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestLookup_BuiltIn(t *testing.T) {
+	for _, name := range []string{"", Picsum, Local} {
+		p, err := Lookup(name, urlbuilder.DefaultBaseURL, filepath.Join(t.TempDir(), "missing.json"))
+		if err != nil {
+			t.Fatalf("Lookup(%q) failed: %v", name, err)
+		}
+		if name != "" && p.Name() != name {
+			t.Errorf("Lookup(%q).Name() = %q", name, p.Name())
+		}
+	}
+}
+
+func TestLookup_Configured(t *testing.T) {
+	// GIVEN
+	path := writeConfig(t, `{"providers": {"placehold": {"url": "https://placehold.co/{width}x{height}.{ext}", "ext": "png"}}}`)
+
+	// WHEN
+	p, err := Lookup("placehold", urlbuilder.DefaultBaseURL, path)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if p.Name() != "placehold" {
+		t.Errorf("Expected placehold provider, got %q", p.Name())
+	}
+}
+
+func TestLookup_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		lookup  string
+		wantErr string
+	}{
+		{"unknown", `{"providers": {"corp": {"url": "https://assets.example.com/{width}"}}}`, "other", "must be one of picsum, local, corp"},
+		{"invalid JSON", `{"providers": `, "corp", "invalid provider configuration"},
+		{"missing url", `{"providers": {"corp": {}}}`, "corp", "has no url"},
+		{"unknown placeholder", `{"providers": {"corp": {"url": "https://assets.example.com/{size}"}}}`, "corp", "unknown placeholder {size}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Lookup(tt.lookup, urlbuilder.DefaultBaseURL, writeConfig(t, tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLookup_UnknownWithoutConfig(t *testing.T) {
+	_, err := Lookup("corp", urlbuilder.DefaultBaseURL, filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil || !strings.Contains(err.Error(), `unknown provider "corp", must be one of picsum, local`) {
+		t.Errorf("Expected unknown provider error, got %v", err)
+	}
+}
+
+// pagedProvider serves a catalog of total images through List
+type pagedProvider struct {
+	Provider
+	total int
+}
+
+func (p *pagedProvider) List(_ context.Context, _ httpclient.Getter, page, limit int) ([]imageinfo.Info, error) {
+	var images []imageinfo.Info
+	for i := (page - 1) * limit; i < page*limit && i < p.total; i++ {
+		images = append(images, imageinfo.Info{})
+	}
+	return images, nil
+}
+
+func TestListAll(t *testing.T) {
+	images, err := ListAll(context.Background(), &pagedProvider{total: 7}, nil, 1, 3)
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if len(images) != 7 {
+		t.Errorf("Expected 7 images, got %d", len(images))
+	}
+}
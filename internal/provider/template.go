@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/nametemplate"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// defaultExt is the file extension of templated providers that do not set one
+const defaultExt = "jpg"
+
+// Paging placeholders of the list URL, in addition to the name template placeholders
+const (
+	pagePlaceholder  = "{page}"
+	limitPlaceholder = "{limit}"
+)
+
+/*
+TemplateConfig describes a placeholder image service by URL templates using
+the --name-template placeholders. The request options a template has no
+placeholder for are rejected, and a template with an {id} or {seed}
+placeholder requires the option. --format is expanded as {ext}.
+*/
+type TemplateConfig struct {
+	// URL is the image URL template, e.g. https://placehold.co/{width}x{height}.{ext}
+	URL string `json:"url"`
+	// Filename overrides the picsum.photos style default filename
+	Filename string `json:"filename,omitempty"`
+	// Ext is the extension of the images served, jpg if empty
+	Ext string `json:"ext,omitempty"`
+	// InfoURL returns the metadata of an image in the picsum.photos format, selected by {id} or {seed}
+	InfoURL string `json:"info_url,omitempty"`
+	// ListURL returns a page of images in the picsum.photos format, paged by {page} and {limit}
+	ListURL string `json:"list_url,omitempty"`
+}
+
+// configFile is the layout of the provider configuration file
+type configFile struct {
+	Providers map[string]TemplateConfig `json:"providers"`
+}
+
+// LoadConfig reads the templated providers defined in the configuration file at path
+func LoadConfig(path string) (map[string]TemplateConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider configuration: %v", err)
+	}
+	var config configFile
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid provider configuration %s: %v", path, err)
+	}
+	return config.Providers, nil
+}
+
+// templateProvider builds URLs and filenames by expanding the templates of its configuration
+type templateProvider struct {
+	name   string
+	config TemplateConfig
+}
+
+// NewTemplate creates a provider from its configuration, validating every template
+func NewTemplate(name string, config TemplateConfig) (Provider, error) {
+	if name == Picsum || name == Local {
+		return nil, fmt.Errorf("provider %q is built in and cannot be configured", name)
+	}
+	if config.URL == "" {
+		return nil, fmt.Errorf("provider %q has no url", name)
+	}
+	if config.Ext == "" {
+		config.Ext = defaultExt
+	}
+	listURL := strings.NewReplacer(pagePlaceholder, "", limitPlaceholder, "").Replace(config.ListURL)
+	for _, template := range []string{config.URL, config.Filename, config.InfoURL, listURL} {
+		if err := nametemplate.Validate(template); err != nil {
+			return nil, fmt.Errorf("provider %q: %v", name, err)
+		}
+	}
+	return &templateProvider{name: name, config: config}, nil
+}
+
+func (p *templateProvider) Name() string {
+	return p.name
+}
+
+// check rejects the request options the URL template cannot express
+func (p *templateProvider) check(req Request) error {
+	uses := func(placeholder string) bool { return nametemplate.Uses(p.config.URL, placeholder) }
+	switch {
+	case req.ImageID != "" && !uses(nametemplate.ID):
+		return fmt.Errorf("provider %s does not support --id", p.name)
+	case req.ImageID == "" && uses(nametemplate.ID):
+		return fmt.Errorf("provider %s requires --id", p.name)
+	case req.Seed != "" && !uses(nametemplate.Seed):
+		return fmt.Errorf("provider %s does not support --seed", p.name)
+	case req.Seed == "" && uses(nametemplate.Seed):
+		return fmt.Errorf("provider %s requires --seed", p.name)
+	case req.Grayscale && !uses(nametemplate.Gray):
+		return fmt.Errorf("provider %s does not support --gray", p.name)
+	case (req.Blur || req.BlurLevel > 0) && !uses(nametemplate.Blur):
+		return fmt.Errorf("provider %s does not support --blur", p.name)
+	case req.Format != "" && !uses(nametemplate.Ext):
+		return fmt.Errorf("provider %s does not support --format", p.name)
+	}
+	return nil
+}
+
+// values returns the placeholder values of the request, escaped for use in a URL path when escape is set
+func (p *templateProvider) values(req Request, escape bool) (nametemplate.Values, error) {
+	width, height, err := urlbuilder.ParseSize(req.Args)
+	if err != nil {
+		return nametemplate.Values{}, err
+	}
+	values := nametemplate.Values{
+		ID:        req.ImageID,
+		Seed:      req.Seed,
+		Width:     width,
+		Height:    height,
+		Grayscale: req.Grayscale,
+		Blur:      req.Blur,
+		BlurLevel: req.BlurLevel,
+		Date:      time.Now(),
+		N:         1,
+		Ext:       p.ext(req),
+	}
+	if escape {
+		values.ID = url.PathEscape(values.ID)
+		values.Seed = url.PathEscape(values.Seed)
+	}
+	return values, nil
+}
+
+func (p *templateProvider) BuildURL(req Request) (string, error) {
+	if err := p.check(req); err != nil {
+		return "", err
+	}
+	values, err := p.values(req, true)
+	if err != nil {
+		return "", err
+	}
+	return nametemplate.Expand(p.config.URL, values), nil
+}
+
+func (p *templateProvider) BuildFilename(req Request) (string, error) {
+	if p.config.Filename != "" {
+		values, err := p.values(req, false)
+		if err != nil {
+			return "", err
+		}
+		return nametemplate.Expand(p.config.Filename, values), nil
+	}
+
+	_, filename, err := urlbuilder.BuildURL(urlbuilder.DefaultBaseURL, req.Args, req.ImageID, req.Seed, req.Grayscale, req.Blur, req.BlurLevel, req.Format)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + p.ext(req), nil
+}
+
+// ext returns the requested format, or the extension of the configured images
+func (p *templateProvider) ext(req Request) string {
+	if req.Format != "" {
+		return req.Format
+	}
+	return p.config.Ext
+}
+
+func (p *templateProvider) List(ctx context.Context, client httpclient.Getter, page, limit int) ([]imageinfo.Info, error) {
+	if p.config.ListURL == "" {
+		return nil, fmt.Errorf("provider %s does not support listing images", p.name)
+	}
+	listURL := strings.NewReplacer(pagePlaceholder, strconv.Itoa(page), limitPlaceholder, strconv.Itoa(limit)).Replace(p.config.ListURL)
+
+	var images []imageinfo.Info
+	if err := getJSON(ctx, client, listURL, &images); err != nil {
+		return nil, fmt.Errorf("failed to list images: %v", err)
+	}
+	return images, nil
+}
+
+func (p *templateProvider) Info(ctx context.Context, client httpclient.Getter, imageID, seed string) (*imageinfo.Info, error) {
+	if p.config.InfoURL == "" {
+		return nil, fmt.Errorf("provider %s does not support image metadata", p.name)
+	}
+	if imageID == "" && seed == "" {
+		return nil, fmt.Errorf("one of --id or --seed is required")
+	}
+	values := nametemplate.Values{ID: url.PathEscape(imageID), Seed: url.PathEscape(seed)}
+
+	var info imageinfo.Info
+	if err := getJSON(ctx, client, nametemplate.Expand(p.config.InfoURL, values), &info); err != nil {
+		return nil, fmt.Errorf("failed to fetch image info: %v", err)
+	}
+	return &info, nil
+}
+
+// NumberedURL keeps imageURL as it is, the template owns the syntax of its query
+func (p *templateProvider) NumberedURL(imageURL string, _ int) string {
+	return imageURL
+}
+
+// Client is nil, templated providers fetch their images over HTTP
+func (p *templateProvider) Client() httpclient.Getter {
+	return nil
+}
+
+// getJSON fetches rawURL and decodes its JSON body into value
+func getJSON(ctx context.Context, client httpclient.Getter, rawURL string, value any) error {
+	resp, err := client.Get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// jsonClient is an httpclient.Getter answering every request with a JSON body
+type jsonClient struct {
+	body string
+	urls []string
+}
+
+func (c *jsonClient) Get(_ context.Context, url string) (*http.Response, error) {
+	c.urls = append(c.urls, url)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+	}, nil
+}
+
+func newTemplate(t *testing.T, config TemplateConfig) Provider {
+	t.Helper()
+	p, err := NewTemplate("corp", config)
+	if err != nil {
+		t.Fatalf("NewTemplate failed: %v", err)
+	}
+	return p
+}
+
+func TestTemplate_BuildURL(t *testing.T) {
+	p := newTemplate(t, TemplateConfig{URL: "https://assets.example.com/{seed}/{width}x{height}.{ext}?{gray}"})
+
+	got, err := p.BuildURL(Request{Args: []string{"200", "100"}, Seed: "a b", Grayscale: true})
+
+	if err != nil {
+		t.Fatalf("BuildURL failed: %v", err)
+	}
+	if got != "https://assets.example.com/a%20b/200x100.jpg?gray" {
+		t.Errorf("Unexpected URL %q", got)
+	}
+}
+
+func TestTemplate_RejectsUnsupportedOptions(t *testing.T) {
+	solid := newTemplate(t, TemplateConfig{URL: "https://placehold.co/{width}x{height}"})
+	byID := newTemplate(t, TemplateConfig{URL: "https://assets.example.com/{id}/{width}"})
+
+	tests := []struct {
+		name    string
+		p       Provider
+		req     Request
+		wantErr string
+	}{
+		{"id", solid, Request{Args: []string{"200"}, ImageID: "1"}, "does not support --id"},
+		{"seed", solid, Request{Args: []string{"200"}, Seed: "x"}, "does not support --seed"},
+		{"gray", solid, Request{Args: []string{"200"}, Grayscale: true}, "does not support --gray"},
+		{"blur", solid, Request{Args: []string{"200"}, BlurLevel: 3}, "does not support --blur"},
+		{"format", solid, Request{Args: []string{"200"}, Format: "webp"}, "does not support --format"},
+		{"required id", byID, Request{Args: []string{"200"}}, "requires --id"},
+		{"invalid size", solid, Request{Args: []string{"x"}}, "invalid number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.p.BuildURL(tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTemplate_BuildFilename(t *testing.T) {
+	tests := []struct {
+		name   string
+		config TemplateConfig
+		want   string
+	}{
+		{"default naming with extension", TemplateConfig{URL: "https://placehold.co/{width}x{height}.png", Ext: "png"}, "200x100.png"},
+		{"filename template", TemplateConfig{URL: "https://placehold.co/{width}x{height}", Filename: "ph_{width}_{height}.{ext}"}, "ph_200_100.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTemplate(t, tt.config).BuildFilename(Request{Args: []string{"200", "100"}})
+			if err != nil {
+				t.Fatalf("BuildFilename failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildFilename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplate_Format(t *testing.T) {
+	p := newTemplate(t, TemplateConfig{URL: "https://placehold.co/{width}x{height}.{ext}", Ext: "png"})
+	req := Request{Args: []string{"200", "100"}, Format: "webp"}
+
+	imageURL, err := p.BuildURL(req)
+	if err != nil || imageURL != "https://placehold.co/200x100.webp" {
+		t.Errorf("Unexpected URL %q, error %v", imageURL, err)
+	}
+	filename, err := p.BuildFilename(req)
+	if err != nil || filename != "200x100.webp" {
+		t.Errorf("Unexpected filename %q, error %v", filename, err)
+	}
+}
+
+func TestTemplate_InfoAndList(t *testing.T) {
+	p := newTemplate(t, TemplateConfig{
+		URL:     "https://assets.example.com/{id}/{width}",
+		InfoURL: "https://assets.example.com/{id}/info",
+		ListURL: "https://assets.example.com/list?page={page}&per_page={limit}",
+	})
+
+	infoClient := &jsonClient{body: `{"id": "7", "author": "Design Team", "width": 800, "height": 600}`}
+	info, err := p.Info(context.Background(), infoClient, "7", "")
+	if err != nil || info.Author != "Design Team" || infoClient.urls[0] != "https://assets.example.com/7/info" {
+		t.Errorf("Unexpected info %+v, %v, %v", info, err, infoClient.urls)
+	}
+
+	listClient := &jsonClient{body: `[{"id": "1"}, {"id": "2"}]`}
+	images, err := p.List(context.Background(), listClient, 3, 2)
+	if err != nil || len(images) != 2 || listClient.urls[0] != "https://assets.example.com/list?page=3&per_page=2" {
+		t.Errorf("Unexpected list %+v, %v, %v", images, err, listClient.urls)
+	}
+}
+
+func TestTemplate_InfoAndListUnsupported(t *testing.T) {
+	p := newTemplate(t, TemplateConfig{URL: "https://placehold.co/{width}"})
+	if _, err := p.Info(context.Background(), &jsonClient{}, "1", ""); err == nil {
+		t.Error("Expected Info to fail without an info_url")
+	}
+	if _, err := p.List(context.Background(), &jsonClient{}, 1, 30); err == nil {
+		t.Error("Expected List to fail without a list_url")
+	}
+}
+
+func TestTemplate_NumberedURL(t *testing.T) {
+	p := newTemplate(t, TemplateConfig{URL: "https://placehold.co/{width}?text=hi"})
+	if got := p.NumberedURL("https://placehold.co/200?text=hi", 3); got != "https://placehold.co/200?text=hi" {
+		t.Errorf("Expected a templated URL to be kept, got %q", got)
+	}
+}
+
+func TestTemplate_Client(t *testing.T) {
+	p := newTemplate(t, TemplateConfig{URL: "https://placehold.co/{width}"})
+	if client := p.Client(); client != nil {
+		t.Errorf("Expected a templated provider to fetch over HTTP, got %T", client)
+	}
+}
+
+func TestNewTemplate_BuiltInName(t *testing.T) {
+	if _, err := NewTemplate(Picsum, TemplateConfig{URL: "https://example.com/{width}"}); err == nil {
+		t.Error("Expected a built-in provider name to be rejected")
+	}
+}
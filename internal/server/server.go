@@ -0,0 +1,134 @@
+/*
+Package server to answer picsum.photos image URLs from the cache, fetching from the provider on a miss
+*/
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/console"
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/provider"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// DefaultAddr is the address the server listens on by default, reachable from this machine only
+const DefaultAddr = "localhost:8080"
+
+// shutdownTimeout is how long in-flight requests may take to finish once the server stops
+const shutdownTimeout = 5 * time.Second
+
+// forwardedHeaders are the upstream response headers passed on to clients
+var forwardedHeaders = []string{"Content-Type", "Content-Length", "Content-Disposition", "Picsum-ID", "Cache-Control", "Last-Modified", "ETag"}
+
+// Handler implements http.Handler by answering image URLs through a provider
+type Handler struct {
+	provider provider.Provider
+	client   httpclient.Getter
+	quiet    bool
+}
+
+/*
+NewHandler creates a Handler that translates the image URLs it receives into
+requests to p and fetches them with client, usually wrapped in a cache.
+Every request is logged unless quiet is set.
+*/
+func NewHandler(p provider.Provider, client httpclient.Getter, quiet bool) *Handler {
+	return &Handler{provider: p, client: client, quiet: quiet}
+}
+
+// ServeHTTP answers a single image request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	status := h.serve(w, r)
+	if !h.quiet {
+		console.Stdoutln("%s %s %d %s", r.Method, r.URL.RequestURI(), status, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// serve writes the response and returns its status code
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		return writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+
+	route, err := urlbuilder.ParsePath(r.URL.Path, r.URL.Query())
+	if err != nil {
+		return writeError(w, http.StatusNotFound, err.Error())
+	}
+	imageURL, err := h.provider.BuildURL(*route)
+	if err != nil {
+		return writeError(w, http.StatusNotFound, err.Error())
+	}
+
+	// A HEAD request is passed on as such, the image is not fetched only to be dropped
+	var resp *http.Response
+	if r.Method == http.MethodHead {
+		resp, err = httpclient.Head(r.Context(), h.client, imageURL)
+	} else {
+		resp, err = h.client.Get(r.Context(), imageURL)
+	}
+	if err != nil {
+		return writeError(w, http.StatusBadGateway, err.Error())
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	for _, name := range forwardedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			w.Header().Set(name, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	if r.Method == http.MethodGet {
+		// The client may go away mid-transfer, there is nobody left to report to
+		_, _ = io.Copy(w, resp.Body)
+	}
+	return resp.StatusCode
+}
+
+func writeError(w http.ResponseWriter, status int, message string) int {
+	http.Error(w, message, status)
+	return status
+}
+
+/*
+ListenAndServe serves handler on addr until ctx is done, then lets in-flight
+requests finish. ready, when not nil, is called with the bound address once
+the server accepts connections.
+*/
+func ListenAndServe(ctx context.Context, addr string, handler http.Handler, ready func(addr net.Addr)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Serve(listener) }()
+	if ready != nil {
+		ready(listener.Addr())
+	}
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server failed: %v", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to stop server: %v", err)
+	}
+	if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %v", err)
+	}
+	return nil
+}
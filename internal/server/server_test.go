@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/provider"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// mockClient is a mock httpclient.Getter recording requested URLs
+type mockClient struct {
+	urls   []string
+	status int
+	err    error
+}
+
+func (m *mockClient) Get(_ context.Context, url string) (*http.Response, error) {
+	m.urls = append(m.urls, url)
+	if m.err != nil {
+		return nil, m.err
+	}
+	status := m.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"image/jpeg"}, "Picsum-Id": {"237"}, "Set-Cookie": {"x=1"}},
+		Body:       io.NopCloser(strings.NewReader("image bytes")),
+	}, nil
+}
+
+func TestHandler_ServesImageRoutes(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/200/300", "https://picsum.photos/200/300"},
+		{"/id/237/200", "https://picsum.photos/id/237/200"},
+		{"/seed/picsum/200/300.jpg?grayscale&blur=2", "https://picsum.photos/seed/picsum/200/300.jpg?grayscale&blur=2"},
+		{"/id/237/300.webp", "https://picsum.photos/id/237/300.webp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			// GIVEN
+			client := &mockClient{}
+			recorder := httptest.NewRecorder()
+
+			// WHEN
+			NewHandler(provider.NewPicsum(provider.Picsum, urlbuilder.DefaultBaseURL), client, true).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// THEN
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body)
+			}
+			if len(client.urls) != 1 || client.urls[0] != tt.want {
+				t.Errorf("Expected upstream URL %q, got %v", tt.want, client.urls)
+			}
+			if recorder.Body.String() != "image bytes" {
+				t.Errorf("Unexpected body %q", recorder.Body)
+			}
+			if recorder.Header().Get("Picsum-ID") != "237" || recorder.Header().Get("Content-Type") != "image/jpeg" {
+				t.Errorf("Expected image headers to be forwarded, got %v", recorder.Header())
+			}
+			if recorder.Header().Get("Set-Cookie") != "" {
+				t.Error("Expected Set-Cookie not to be forwarded")
+			}
+		})
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		client *mockClient
+		status int
+	}{
+		{"unknown route", http.MethodGet, "/v2/list", &mockClient{}, http.StatusNotFound},
+		{"invalid blur", http.MethodGet, "/200?blur=20", &mockClient{}, http.StatusNotFound},
+		{"method", http.MethodPost, "/200", &mockClient{}, http.StatusMethodNotAllowed},
+		{"upstream failure", http.MethodGet, "/id/1/200", &mockClient{err: errors.New("offline")}, http.StatusBadGateway},
+		{"upstream status", http.MethodGet, "/id/99999/200", &mockClient{status: http.StatusNotFound}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			NewHandler(provider.NewPicsum(provider.Picsum, urlbuilder.DefaultBaseURL), tt.client, true).ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+			if recorder.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, recorder.Code)
+			}
+		})
+	}
+}
+
+// headClient is a mock httpclient.HeadGetter recording the URLs requested with HEAD
+type headClient struct {
+	mockClient
+	heads []string
+}
+
+func (m *headClient) Head(_ context.Context, url string) (*http.Response, error) {
+	m.heads = append(m.heads, url)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"image/jpeg"}, "Content-Length": {"11"}},
+		Body:       http.NoBody,
+	}, nil
+}
+
+func TestHandler_Head(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewHandler(provider.NewPicsum(provider.Picsum, urlbuilder.DefaultBaseURL), &mockClient{}, true).ServeHTTP(recorder, httptest.NewRequest(http.MethodHead, "/200", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 {
+		t.Errorf("Expected an empty 200 response, got %d with %d bytes", recorder.Code, recorder.Body.Len())
+	}
+}
+
+func TestHandler_HeadIsPassedUpstream(t *testing.T) {
+	// GIVEN
+	client := &headClient{}
+	recorder := httptest.NewRecorder()
+
+	// WHEN
+	NewHandler(provider.NewPicsum(provider.Picsum, urlbuilder.DefaultBaseURL), client, true).ServeHTTP(recorder, httptest.NewRequest(http.MethodHead, "/id/237/200", nil))
+
+	// THEN
+	if len(client.urls) != 0 {
+		t.Errorf("Expected no GET request, got %v", client.urls)
+	}
+	if len(client.heads) != 1 || client.heads[0] != "https://picsum.photos/id/237/200" {
+		t.Errorf("Expected a HEAD request for the image, got %v", client.heads)
+	}
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Length") != "11" {
+		t.Errorf("Expected the upstream headers, got %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestListenAndServe_StopsWithContext(t *testing.T) {
+	// GIVEN
+	ctx, cancel := context.WithCancel(context.Background())
+	addrs := make(chan net.Addr, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- ListenAndServe(ctx, "127.0.0.1:0", NewHandler(provider.NewPicsum(provider.Picsum, urlbuilder.DefaultBaseURL), &mockClient{}, true), func(addr net.Addr) { addrs <- addr })
+	}()
+
+	// WHEN
+	addr := <-addrs
+	resp, err := http.Get("http://" + addr.String() + "/id/237/200")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	cancel()
+
+	// THEN
+	if string(body) != "image bytes" {
+		t.Errorf("Unexpected body %q", body)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not stop")
+	}
+}
+
+func TestListenAndServe_InvalidAddress(t *testing.T) {
+	err := ListenAndServe(context.Background(), "invalid:address:1", NewHandler(provider.NewPicsum(provider.Picsum, urlbuilder.DefaultBaseURL), &mockClient{}, true), nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to listen") {
+		t.Errorf("Expected listen error, got %v", err)
+	}
+}
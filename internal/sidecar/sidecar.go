@@ -0,0 +1,141 @@
+/*
+Package sidecar to record the provenance of a downloaded image in a JSON file
+*/
+package sidecar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/siakhooi/picsum/internal/output"
+)
+
+// Extension is the file extension of sidecar files
+const Extension = ".json"
+
+// headersOfInterest are the response headers copied into the sidecar
+var headersOfInterest = []string{
+	"Content-Type",
+	"Content-Length",
+	"Content-Disposition",
+	"Picsum-ID",
+	"Last-Modified",
+	"ETag",
+	"Cache-Control",
+	"Date",
+}
+
+// Metadata is the provenance record written next to a downloaded image
+type Metadata struct {
+	RequestURL    string            `json:"request_url"`
+	FinalURL      string            `json:"final_url"`
+	PicsumID      string            `json:"picsum_id,omitempty"`
+	Author        string            `json:"author,omitempty"`
+	SourceURL     string            `json:"source_url,omitempty"`
+	DownloadedAt  time.Time         `json:"downloaded_at"`
+	Headers       map[string]string `json:"headers"`
+	ContentLength int64             `json:"content_length"`
+	SHA256        string            `json:"sha256"`
+}
+
+// Recorder observes an HTTP response body while it is saved
+type Recorder struct {
+	metadata Metadata
+	hash     hash.Hash
+}
+
+// recordingBody hashes and counts the bytes read from the wrapped body
+type recordingBody struct {
+	io.ReadCloser
+	recorder *Recorder
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = b.recorder.hash.Write(p[:n])
+		b.recorder.metadata.ContentLength += int64(n)
+	}
+	return n, err
+}
+
+/*
+NewRecorder captures the request and response details of resp and replaces
+resp.Body so that the bytes read from it are hashed and counted
+*/
+func NewRecorder(requestURL string, resp *http.Response) *Recorder {
+	r := &Recorder{
+		metadata: Metadata{
+			RequestURL:   requestURL,
+			FinalURL:     requestURL,
+			PicsumID:     resp.Header.Get("Picsum-ID"),
+			DownloadedAt: time.Now().UTC(),
+			Headers:      map[string]string{},
+		},
+		hash: sha256.New(),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		r.metadata.FinalURL = resp.Request.URL.String()
+	}
+	for _, name := range headersOfInterest {
+		if value := resp.Header.Get(name); value != "" {
+			r.metadata.Headers[name] = value
+		}
+	}
+	resp.Body = &recordingBody{ReadCloser: resp.Body, recorder: r}
+	return r
+}
+
+// Metadata returns the provenance record of the bytes read so far
+func (r *Recorder) Metadata() *Metadata {
+	metadata := r.metadata
+	metadata.SHA256 = hex.EncodeToString(r.hash.Sum(nil))
+	return &metadata
+}
+
+/*
+Path returns the sidecar file path of the given image path, the image path
+with Extension appended, so images that differ only in their extension get
+sidecars of their own
+*/
+func Path(imagePath string) string {
+	return imagePath + Extension
+}
+
+// Write saves the metadata as a sidecar file next to imagePath
+func Write(imagePath string, metadata *Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %v", err)
+	}
+	// The sidecar follows its image, so it is replaced without asking and no context is needed
+	err = output.WriteFile(context.Background(), Path(imagePath), true, func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write metadata: %v", err)
+	}
+	return nil
+}
+
+// Read loads the sidecar file of imagePath
+func Read(imagePath string) (*Metadata, error) {
+	data, err := os.ReadFile(Path(imagePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %v", err)
+	}
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata %s: %v", Path(imagePath), err)
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,153 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewRecorder_CapturesResponse(t *testing.T) {
+	// GIVEN
+	finalURL, _ := url.Parse("https://fastly.picsum.photos/id/237/200/300.jpg?hmac=abc")
+	resp := &http.Response{
+		Header: http.Header{
+			"Content-Type": []string{"image/jpeg"},
+			"Picsum-Id":    []string{"237"},
+			"X-Ignored":    []string{"value"},
+		},
+		Request: &http.Request{URL: finalURL},
+		Body:    io.NopCloser(strings.NewReader("hello")),
+	}
+
+	// WHEN
+	recorder := NewRecorder("https://picsum.photos/200/300", resp)
+	_, _ = io.ReadAll(resp.Body)
+	metadata := recorder.Metadata()
+
+	// THEN
+	if metadata.RequestURL != "https://picsum.photos/200/300" {
+		t.Errorf("Unexpected request URL %q", metadata.RequestURL)
+	}
+	if metadata.FinalURL != finalURL.String() {
+		t.Errorf("Unexpected final URL %q", metadata.FinalURL)
+	}
+	if metadata.PicsumID != "237" {
+		t.Errorf("Expected Picsum ID 237, got %q", metadata.PicsumID)
+	}
+	if metadata.ContentLength != 5 {
+		t.Errorf("Expected content length 5, got %d", metadata.ContentLength)
+	}
+	// sha256("hello")
+	expectedHash := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if metadata.SHA256 != expectedHash {
+		t.Errorf("Expected SHA-256 %s, got %s", expectedHash, metadata.SHA256)
+	}
+	if metadata.Headers["Content-Type"] != "image/jpeg" {
+		t.Errorf("Expected Content-Type header to be recorded, got %v", metadata.Headers)
+	}
+	if _, ok := metadata.Headers["X-Ignored"]; ok {
+		t.Error("Expected uninteresting headers to be skipped")
+	}
+	if metadata.DownloadedAt.IsZero() {
+		t.Error("Expected download time to be recorded")
+	}
+}
+
+func TestNewRecorder_NoRequest(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+
+	metadata := NewRecorder("https://picsum.photos/200", resp).Metadata()
+
+	if metadata.FinalURL != "https://picsum.photos/200" {
+		t.Errorf("Expected final URL to fall back to request URL, got %q", metadata.FinalURL)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		imagePath string
+		expected  string
+	}{
+		{"id_237_200x300.jpg", "id_237_200x300.jpg.json"},
+		{"out/hero.webp", "out/hero.webp.json"},
+		{"noext", "noext.json"},
+		{"x.json", "x.json.json"},
+	}
+
+	for _, tt := range tests {
+		if got := Path(tt.imagePath); got != tt.expected {
+			t.Errorf("Path(%q) = %q, want %q", tt.imagePath, got, tt.expected)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	// GIVEN
+	imagePath := filepath.Join(t.TempDir(), "image.jpg")
+	metadata := &Metadata{RequestURL: "https://picsum.photos/200", PicsumID: "10", SHA256: "abc"}
+
+	// WHEN
+	err := Write(imagePath, metadata)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := os.ReadFile(Path(imagePath))
+	if err != nil {
+		t.Fatalf("Failed to read sidecar: %v", err)
+	}
+	var got Metadata
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Sidecar is not valid JSON: %v", err)
+	}
+	if got.PicsumID != "10" || got.SHA256 != "abc" {
+		t.Errorf("Unexpected sidecar content %+v", got)
+	}
+}
+
+func TestWrite_InvalidPath(t *testing.T) {
+	err := Write("/nonexistent/directory/image.jpg", &Metadata{})
+	if err == nil || !strings.Contains(err.Error(), "failed to write metadata") {
+		t.Errorf("Expected write error, got %v", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	// GIVEN
+	imagePath := filepath.Join(t.TempDir(), "image.jpg")
+	if err := Write(imagePath, &Metadata{RequestURL: "https://picsum.photos/200", SHA256: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN
+	got, err := Read(imagePath)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got.RequestURL != "https://picsum.photos/200" || got.SHA256 != "abc" {
+		t.Errorf("Unexpected metadata %+v", got)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.jpg")
+	if err := os.WriteFile(Path(invalid), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(filepath.Join(dir, "missing.jpg")); err == nil || !strings.Contains(err.Error(), "failed to read metadata") {
+		t.Errorf("Expected read error, got %v", err)
+	}
+	if _, err := Read(invalid); err == nil || !strings.Contains(err.Error(), "invalid metadata") {
+		t.Errorf("Expected invalid metadata error, got %v", err)
+	}
+}
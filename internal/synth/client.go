@@ -0,0 +1,197 @@
+package synth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image/jpeg"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/siakhooi/picsum/internal/httpclient"
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+// CatalogSize is the number of images in the generated catalog, with IDs 0 to CatalogSize-1
+const CatalogSize = 1000
+
+// Author is reported as the author of every generated image
+const Author = "picsum local generator"
+
+// jpegQuality is the quality generated images are encoded with
+const jpegQuality = 90
+
+// originalHeights are the heights of the catalog images, all 5000 pixels wide
+var originalHeights = []int{3333, 2813, 3750, 5000, 2500}
+
+/*
+Client implements httpclient.Getter by answering picsum.photos URLs under
+its base URL with generated images. Like picsum.photos, a seed selects an image of the
+catalog and a random request picks any of them, reported in the Picsum-ID
+header so that it can be fetched again with --id.
+*/
+type Client struct {
+	baseURL string
+}
+
+// NewClient creates a Client answering the URLs under baseURL
+func NewClient(baseURL string) httpclient.Getter {
+	return &Client{baseURL: baseURL}
+}
+
+// Get generates the response to an image, info or list URL
+func (c *Client) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	rest, ok := strings.CutPrefix(rawURL, c.baseURL)
+	if !ok {
+		return nil, fmt.Errorf("local provider cannot serve %s", rawURL)
+	}
+	u, err := url.Parse(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case u.Path == "/v2/list":
+		return listResponse(req, c.baseURL, u.Query())
+	case strings.HasSuffix(u.Path, "/info"):
+		return infoResponse(req, c.baseURL, strings.TrimSuffix(u.Path, "/info"))
+	}
+	return imageResponse(req, u)
+}
+
+// SeedImageID returns the catalog image a seed selects
+func SeedImageID(seed string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(seed))
+	return strconv.Itoa(int(hash.Sum32() % CatalogSize))
+}
+
+// ImageInfo returns the catalog entry of a generated image served under baseURL
+func ImageInfo(baseURL, imageID string) imageinfo.Info {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(imageID))
+	height := originalHeights[hash.Sum32()%uint32(len(originalHeights))]
+	return imageinfo.Info{
+		ID:          imageID,
+		Author:      Author,
+		Width:       MaxSize,
+		Height:      height,
+		URL:         fmt.Sprintf("%s/id/%s", baseURL, url.PathEscape(imageID)),
+		DownloadURL: fmt.Sprintf("%s/id/%s/%d/%d", baseURL, url.PathEscape(imageID), MaxSize, height),
+	}
+}
+
+func imageResponse(req *http.Request, u *url.URL) (*http.Response, error) {
+	route, err := urlbuilder.ParsePath(u.Path, u.Query())
+	if err != nil {
+		return textResponse(req, http.StatusNotFound, err.Error()), nil
+	}
+	width, height, err := urlbuilder.ParseSize(route.Args)
+	if err != nil {
+		return textResponse(req, http.StatusNotFound, err.Error()), nil
+	}
+	if width > MaxSize || height > MaxSize {
+		return textResponse(req, http.StatusBadRequest, fmt.Sprintf("size must not exceed %d", MaxSize)), nil
+	}
+
+	imageID := route.ImageID
+	switch {
+	case route.Seed != "":
+		imageID = SeedImageID(route.Seed)
+	case imageID == "":
+		imageID = strconv.Itoa(rand.IntN(CatalogSize))
+	}
+	blurLevel := route.BlurLevel
+	if route.Blur {
+		blurLevel = DefaultBlurLevel
+	}
+
+	img := Generate("id/"+imageID, Options{Width: width, Height: height, Grayscale: route.Grayscale, BlurLevel: blurLevel})
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %v", err)
+	}
+
+	resp := newResponse(req, http.StatusOK, "image/jpeg", buf.Bytes())
+	resp.Header.Set("Picsum-ID", imageID)
+	return resp, nil
+}
+
+func infoResponse(req *http.Request, baseURL, path string) (*http.Response, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != 2 {
+		return textResponse(req, http.StatusNotFound, "not found"), nil
+	}
+	imageID, err := url.PathUnescape(segments[1])
+	if err != nil {
+		return textResponse(req, http.StatusNotFound, err.Error()), nil
+	}
+	switch segments[0] {
+	case "id":
+	case "seed":
+		imageID = SeedImageID(imageID)
+	default:
+		return textResponse(req, http.StatusNotFound, "not found"), nil
+	}
+	return jsonResponse(req, ImageInfo(baseURL, imageID))
+}
+
+func listResponse(req *http.Request, baseURL string, query url.Values) (*http.Response, error) {
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = 30
+	}
+
+	images := []imageinfo.Info{}
+	for i := (page - 1) * limit; i < page*limit && i < CatalogSize; i++ {
+		images = append(images, ImageInfo(baseURL, strconv.Itoa(i)))
+	}
+	return jsonResponse(req, images)
+}
+
+func jsonResponse(req *http.Request, value any) (*http.Response, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(req, http.StatusOK, "application/json", data), nil
+}
+
+func textResponse(req *http.Request, status int, message string) *http.Response {
+	return newResponse(req, status, "text/plain; charset=utf-8", []byte(message))
+}
+
+func newResponse(req *http.Request, status int, contentType string, body []byte) *http.Response {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,143 @@
+package synth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/siakhooi/picsum/internal/imageinfo"
+	"github.com/siakhooi/picsum/internal/urlbuilder"
+)
+
+func get(t *testing.T, rawURL string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := NewClient(urlbuilder.DefaultBaseURL).Get(context.Background(), rawURL)
+	if err != nil {
+		t.Fatalf("Get(%q) failed: %v", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	return resp, body
+}
+
+func TestClient_Image(t *testing.T) {
+	// WHEN
+	resp, body := get(t, "https://picsum.photos/id/237/40/30?grayscale&blur=2")
+
+	// THEN
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("Picsum-ID") != "237" {
+		t.Errorf("Expected Picsum-ID 237, got %q", resp.Header.Get("Picsum-ID"))
+	}
+	if resp.Request.URL.String() != "https://picsum.photos/id/237/40/30?grayscale&blur=2" {
+		t.Errorf("Unexpected request URL %s", resp.Request.URL)
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected a JPEG image: %v", err)
+	}
+	if config.Width != 40 || config.Height != 30 {
+		t.Errorf("Expected 40x30, got %dx%d", config.Width, config.Height)
+	}
+}
+
+func TestClient_SeedIsDeterministic(t *testing.T) {
+	first, firstBody := get(t, "https://picsum.photos/seed/picsum/20")
+	second, secondBody := get(t, "https://picsum.photos/seed/picsum/20")
+	byID, byIDBody := get(t, "https://picsum.photos/id/"+SeedImageID("picsum")+"/20")
+
+	if string(firstBody) != string(secondBody) || string(firstBody) != string(byIDBody) {
+		t.Error("Expected a seed to always select the same catalog image")
+	}
+	if first.Header.Get("Picsum-ID") != second.Header.Get("Picsum-ID") || first.Header.Get("Picsum-ID") != byID.Header.Get("Picsum-ID") {
+		t.Error("Expected the seed to report its catalog image ID")
+	}
+}
+
+func TestClient_RandomReportsID(t *testing.T) {
+	resp, _ := get(t, "https://picsum.photos/20?random=3")
+	if resp.Header.Get("Picsum-ID") == "" {
+		t.Error("Expected a random image to report its ID")
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"https://picsum.photos/abc", http.StatusNotFound},
+		{"https://picsum.photos/5001/10", http.StatusBadRequest},
+		{"https://picsum.photos/other/1/info", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if resp, _ := get(t, tt.url); resp.StatusCode != tt.status {
+			t.Errorf("Get(%q) status = %d, want %d", tt.url, resp.StatusCode, tt.status)
+		}
+	}
+
+	if _, err := NewClient(urlbuilder.DefaultBaseURL).Get(context.Background(), "https://example.com/200"); err == nil {
+		t.Error("Expected an error for a URL outside the base URL")
+	}
+}
+
+func TestClient_Info(t *testing.T) {
+	resp, body := get(t, "https://picsum.photos/seed/picsum/info")
+
+	var info imageinfo.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		t.Fatalf("Expected JSON info: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || info.ID != SeedImageID("picsum") || info.Author != Author || info.Width != MaxSize {
+		t.Errorf("Unexpected info: %d %+v", resp.StatusCode, info)
+	}
+}
+
+func TestClient_List(t *testing.T) {
+	_, body := get(t, "https://picsum.photos/v2/list?page=2&limit=3")
+	var images []imageinfo.Info
+	if err := json.Unmarshal(body, &images); err != nil {
+		t.Fatalf("Expected JSON list: %v", err)
+	}
+	if len(images) != 3 || images[0].ID != "3" {
+		t.Errorf("Unexpected page: %+v", images)
+	}
+
+	_, body = get(t, "https://picsum.photos/v2/list?page=1000&limit=30")
+	if string(body) != "[]" {
+		t.Errorf("Expected an empty page past the end of the catalog, got %s", body)
+	}
+}
+
+func TestClient_BaseURL(t *testing.T) {
+	// GIVEN
+	client := NewClient("http://localhost:8080/picsum")
+
+	// WHEN
+	resp, err := client.Get(context.Background(), "http://localhost:8080/picsum/id/7/info")
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var info imageinfo.Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("Expected JSON info: %v", err)
+	}
+	if info.URL != "http://localhost:8080/picsum/id/7" {
+		t.Errorf("Expected the info to link to the base URL, got %q", info.URL)
+	}
+	if _, err := client.Get(context.Background(), "https://picsum.photos/id/7/info"); err == nil {
+		t.Error("Expected an error for a URL outside the base URL")
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Package synth to generate deterministic placeholder images without a network
+*/
+package synth
+
+import (
+	"hash/fnv"
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+)
+
+// MaxSize is the largest width or height that is generated, as on picsum.photos
+const MaxSize = 5000
+
+// DefaultBlurLevel is the blur level applied by ?blur without a level
+const DefaultBlurLevel = 1
+
+// blurPasses is the number of box blur passes, three approximate a gaussian blur
+const blurPasses = 3
+
+// pattern draws the background of an image from rng
+type pattern func(img *image.RGBA, rng *rand.Rand)
+
+// patterns are the backgrounds an image is picked from
+var patterns = []pattern{linearGradient, radialGradient, valueNoise, shapes, stripes}
+
+// Options selects the image to generate
+type Options struct {
+	Width     int
+	Height    int
+	Grayscale bool
+	// BlurLevel is 0 for a sharp image or 1-10
+	BlurLevel int
+}
+
+/*
+Generate draws the image identified by key, the same key and size always give
+the same image. The pattern and its colours are derived from key, then the
+grayscale and blur options are applied.
+*/
+func Generate(key string, opts Options) *image.RGBA {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	sum := hash.Sum64()
+	rng := rand.New(rand.NewPCG(sum, sum>>1|1))
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	patterns[rng.IntN(len(patterns))](img, rng)
+
+	if opts.Grayscale {
+		grayscale(img)
+	}
+	if opts.BlurLevel > 0 {
+		boxBlur(img, blurRadius(opts.Width, opts.Height, opts.BlurLevel))
+	}
+	return img
+}
+
+func randomColor(rng *rand.Rand) color.RGBA {
+	return color.RGBA{R: uint8(rng.IntN(256)), G: uint8(rng.IntN(256)), B: uint8(rng.IntN(256)), A: 255}
+}
+
+// mix blends a and b, t is the share of b between 0 and 1
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5) }
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 255}
+}
+
+func linearGradient(img *image.RGBA, rng *rand.Rand) {
+	from, to := randomColor(rng), randomColor(rng)
+	angle := rng.Float64() * 2 * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	span := math.Abs(dx*w) + math.Abs(dy*h)
+	offset := math.Min(0, dx*w) + math.Min(0, dy*h)
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			t := (dx*float64(x) + dy*float64(y) - offset) / math.Max(span, 1)
+			img.SetRGBA(x, y, mix(from, to, t))
+		}
+	}
+}
+
+func radialGradient(img *image.RGBA, rng *rand.Rand) {
+	inner, outer := randomColor(rng), randomColor(rng)
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	cx, cy := rng.Float64()*w, rng.Float64()*h
+	radius := math.Max(math.Hypot(math.Max(cx, w-cx), math.Max(cy, h-cy)), 1)
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			img.SetRGBA(x, y, mix(inner, outer, math.Hypot(float64(x)-cx, float64(y)-cy)/radius))
+		}
+	}
+}
+
+// valueNoise interpolates a coarse grid of random colours
+func valueNoise(img *image.RGBA, rng *rand.Rand) {
+	const cells = 6
+	var grid [cells + 1][cells + 1]color.RGBA
+	for i := range grid {
+		for j := range grid[i] {
+			grid[i][j] = randomColor(rng)
+		}
+	}
+
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	for y := range img.Rect.Dy() {
+		gy := float64(y) / h * cells
+		j, ty := int(gy), smoothstep(gy-math.Floor(gy))
+		for x := range img.Rect.Dx() {
+			gx := float64(x) / w * cells
+			i, tx := int(gx), smoothstep(gx-math.Floor(gx))
+			top := mix(grid[j][i], grid[j][i+1], tx)
+			bottom := mix(grid[j+1][i], grid[j+1][i+1], tx)
+			img.SetRGBA(x, y, mix(top, bottom, ty))
+		}
+	}
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// shapes scatters circles and rectangles over a gradient
+func shapes(img *image.RGBA, rng *rand.Rand) {
+	linearGradient(img, rng)
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	size := math.Max(float64(min(w, h)), 1)
+	for range 6 + rng.IntN(10) {
+		fill := randomColor(rng)
+		cx, cy := rng.Float64()*float64(w), rng.Float64()*float64(h)
+		r := size * (0.05 + rng.Float64()*0.25)
+		circle := rng.IntN(2) == 0
+
+		x0, x1 := max(int(cx-r), 0), min(int(cx+r)+1, w)
+		y0, y1 := max(int(cy-r), 0), min(int(cy+r)+1, h)
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				if !circle || math.Hypot(float64(x)-cx, float64(y)-cy) <= r {
+					img.SetRGBA(x, y, mix(img.RGBAAt(x, y), fill, 0.8))
+				}
+			}
+		}
+	}
+}
+
+func stripes(img *image.RGBA, rng *rand.Rand) {
+	a, b := randomColor(rng), randomColor(rng)
+	width := 8 + rng.Float64()*float64(max(img.Rect.Dx(), img.Rect.Dy()))/6
+	angle := rng.Float64() * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	checker := rng.IntN(2) == 0
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			band := int(math.Floor((dx*float64(x) + dy*float64(y)) / width))
+			if checker {
+				band += int(math.Floor((dx*float64(y) - dy*float64(x)) / width))
+			}
+			if band%2 == 0 {
+				img.SetRGBA(x, y, a)
+			} else {
+				img.SetRGBA(x, y, b)
+			}
+		}
+	}
+}
+
+// grayscale replaces every pixel with its luma
+func grayscale(img *image.RGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		r, g, b := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+		y := uint8((299*r + 587*g + 114*b + 500) / 1000)
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = y, y, y
+	}
+}
+
+// blurRadius scales the box radius with the image so that a level looks alike at any size
+func blurRadius(width, height, level int) int {
+	return max(level*min(width, height)/200, level)
+}
+
+// boxBlur blurs img in place with repeated horizontal and vertical box filters of the given radius
+func boxBlur(img *image.RGBA, radius int) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	buf := make([]uint8, max(w, h)*4)
+	for range blurPasses {
+		for y := range h {
+			blurLine(img.Pix[y*img.Stride:], 4, w, radius, buf)
+		}
+		for x := range w {
+			blurLine(img.Pix[x*4:], img.Stride, h, radius, buf)
+		}
+	}
+}
+
+// blurLine applies a sliding box average to n pixels that are step bytes apart, clamping at the edges
+func blurLine(pix []uint8, step, n, radius int, buf []uint8) {
+	for c := range 3 {
+		at := func(i int) int { return int(pix[min(max(i, 0), n-1)*step+c]) }
+		sum := 0
+		for i := -radius; i <= radius; i++ {
+			sum += at(i)
+		}
+		window := 2*radius + 1
+		for i := range n {
+			buf[i*4+c] = uint8((sum + window/2) / window)
+			sum += at(i+radius+1) - at(i-radius)
+		}
+	}
+	for i := range n {
+		copy(pix[i*step:i*step+3], buf[i*4:i*4+3])
+	}
+}
@@ -0,0 +1,94 @@
+package synth
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerate_Deterministic(t *testing.T) {
+	opts := Options{Width: 64, Height: 48}
+
+	first := Generate("id/237", opts)
+	second := Generate("id/237", opts)
+	other := Generate("id/238", opts)
+
+	if first.Rect.Dx() != 64 || first.Rect.Dy() != 48 {
+		t.Fatalf("Expected a 64x48 image, got %v", first.Rect)
+	}
+	if !bytes.Equal(first.Pix, second.Pix) {
+		t.Error("Expected the same key to generate the same image")
+	}
+	if bytes.Equal(first.Pix, other.Pix) {
+		t.Error("Expected different keys to generate different images")
+	}
+}
+
+func TestGenerate_EveryPattern(t *testing.T) {
+	// Enough keys to hit every pattern, none of which may leave the image blank
+	for i := range 50 {
+		img := Generate(string(rune('a'+i)), Options{Width: 17, Height: 9})
+		blank := true
+		for p := 0; p < len(img.Pix); p += 4 {
+			if img.Pix[p+3] != 255 {
+				t.Fatalf("Expected opaque pixels for key %d", i)
+			}
+			if img.Pix[p] != 0 || img.Pix[p+1] != 0 || img.Pix[p+2] != 0 {
+				blank = false
+			}
+		}
+		if blank {
+			t.Errorf("Expected a drawn image for key %d", i)
+		}
+	}
+}
+
+func TestGenerate_Grayscale(t *testing.T) {
+	img := Generate("id/1", Options{Width: 32, Height: 32, Grayscale: true})
+	for p := 0; p < len(img.Pix); p += 4 {
+		if img.Pix[p] != img.Pix[p+1] || img.Pix[p+1] != img.Pix[p+2] {
+			t.Fatalf("Expected gray pixel at %d, got %v", p/4, img.Pix[p:p+3])
+		}
+	}
+}
+
+func TestGenerate_BlurSmoothsTheImage(t *testing.T) {
+	// variation sums the differences between horizontal neighbours
+	variation := func(level int) int {
+		img := Generate("id/5", Options{Width: 64, Height: 64, BlurLevel: level})
+		total := 0
+		for y := range 64 {
+			for x := 1; x < 64; x++ {
+				a, b := img.RGBAAt(x-1, y), img.RGBAAt(x, y)
+				total += abs(int(a.R)-int(b.R)) + abs(int(a.G)-int(b.G)) + abs(int(a.B)-int(b.B))
+			}
+		}
+		return total
+	}
+
+	sharp, blurred, blurrier := variation(0), variation(1), variation(10)
+	if !(sharp >= blurred && blurred >= blurrier) || sharp == blurrier {
+		t.Errorf("Expected blur to reduce variation, got %d, %d, %d", sharp, blurred, blurrier)
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestBlurRadius(t *testing.T) {
+	tests := []struct {
+		width, height, level, want int
+	}{
+		{100, 100, 1, 1},
+		{2000, 1000, 1, 5},
+		{2000, 1000, 10, 50},
+	}
+	for _, tt := range tests {
+		if got := blurRadius(tt.width, tt.height, tt.level); got != tt.want {
+			t.Errorf("blurRadius(%d, %d, %d) = %d, want %d", tt.width, tt.height, tt.level, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Package transform to crop, resize and pad a downloaded image before it is saved
+*/
+package transform
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+
+	"github.com/siakhooi/picsum/internal/hexcolor"
+	"golang.org/x/image/draw"
+)
+
+// Ways of fitting an image into the --resize box
+const (
+	// FitCover scales the image to fill the box and crops the overflow around the center
+	FitCover = "cover"
+	// FitContain scales the image to fit inside the box and pads the remainder
+	FitContain = "contain"
+)
+
+/*
+MaxSize is the largest width or height of --resize, twice the 5000 pixels
+picsum.photos serves, which keeps the decoded image within 400 MB
+*/
+const MaxSize = 10000
+
+// DefaultPadColor is the color of the borders FitContain adds
+var DefaultPadColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+var (
+	sizePattern = regexp.MustCompile(`^(\d+)x(\d+)$`)
+	cropPattern = regexp.MustCompile(`^(\d+)x(\d+)\+(\d+)\+(\d+)$`)
+)
+
+// Spec is the transformation applied to an image, crop first and resize second
+type Spec struct {
+	// Crop is the region of the downloaded image to keep, empty for all of it
+	Crop image.Rectangle
+	// Resize is the final width and height, zero to keep the size
+	Resize image.Point
+	// Fit is FitCover, FitContain or empty to stretch the image to Resize
+	Fit      string
+	PadColor color.RGBA
+}
+
+/*
+Parse builds the Spec of the --resize WxH, --crop WxH+X+Y, --fit and
+--pad-color options, nil when none of them is set
+*/
+func Parse(resize, crop, fit, padColor string) (*Spec, error) {
+	if resize == "" && crop == "" && fit == "" && padColor == "" {
+		return nil, nil
+	}
+
+	spec := &Spec{Fit: fit, PadColor: DefaultPadColor}
+	if resize != "" {
+		m := sizePattern.FindStringSubmatch(resize)
+		if m == nil {
+			return nil, fmt.Errorf("invalid resize %q, must be WxH", resize)
+		}
+		spec.Resize = image.Pt(atoi(m[1]), atoi(m[2]))
+		if spec.Resize.X < 1 || spec.Resize.Y < 1 {
+			return nil, fmt.Errorf("invalid resize %q, width and height must be positive", resize)
+		}
+		if spec.Resize.X > MaxSize || spec.Resize.Y > MaxSize {
+			return nil, fmt.Errorf("invalid resize %q, width and height must not exceed %d", resize, MaxSize)
+		}
+	}
+	if crop != "" {
+		m := cropPattern.FindStringSubmatch(crop)
+		if m == nil {
+			return nil, fmt.Errorf("invalid crop %q, must be WxH+X+Y", crop)
+		}
+		origin := image.Pt(atoi(m[3]), atoi(m[4]))
+		spec.Crop = image.Rectangle{Min: origin, Max: origin.Add(image.Pt(atoi(m[1]), atoi(m[2])))}
+		if spec.Crop.Empty() {
+			return nil, fmt.Errorf("invalid crop %q, width and height must be positive", crop)
+		}
+	}
+
+	switch fit {
+	case "":
+	case FitCover, FitContain:
+		if resize == "" {
+			return nil, fmt.Errorf("option --fit requires --resize")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fit %q, must be %s or %s", fit, FitCover, FitContain)
+	}
+
+	if padColor != "" {
+		if fit != FitContain {
+			return nil, fmt.Errorf("option --pad-color requires --fit %s", FitContain)
+		}
+		c, err := hexcolor.Parse(padColor)
+		if err != nil {
+			return nil, err
+		}
+		spec.PadColor = c
+	}
+	return spec, nil
+}
+
+// atoi converts the digits matched by a pattern, which cannot fail short of overflow
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// Apply crops and then resizes img as described by spec
+func Apply(img image.Image, spec *Spec) (image.Image, error) {
+	if !spec.Crop.Empty() {
+		crop := spec.Crop.Add(img.Bounds().Min)
+		if !crop.In(img.Bounds()) {
+			return nil, fmt.Errorf("crop %dx%d+%d+%d exceeds the %dx%d image",
+				spec.Crop.Dx(), spec.Crop.Dy(), spec.Crop.Min.X, spec.Crop.Min.Y, img.Bounds().Dx(), img.Bounds().Dy())
+		}
+		cropped := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+		draw.Draw(cropped, cropped.Bounds(), img, crop.Min, draw.Src)
+		img = cropped
+	}
+	if spec.Resize == (image.Point{}) {
+		return img, nil
+	}
+
+	box := image.Rectangle{Max: spec.Resize}
+	dst := image.NewRGBA(box)
+	switch spec.Fit {
+	case FitCover:
+		// Scale the largest centered region of the aspect ratio of the box
+		draw.CatmullRom.Scale(dst, box, img, centered(img.Bounds(), spec.Resize), draw.Src, nil)
+	case FitContain:
+		draw.Draw(dst, box, image.NewUniform(spec.PadColor), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, centered(box, img.Bounds().Size()), img, img.Bounds(), draw.Src, nil)
+	default:
+		draw.CatmullRom.Scale(dst, box, img, img.Bounds(), draw.Src, nil)
+	}
+	return dst, nil
+}
+
+// centered returns the largest rectangle of the aspect ratio of size that fits in bounds, centered on it
+func centered(bounds image.Rectangle, size image.Point) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+	// Compare w/h with size.X/size.Y without dividing
+	if w*size.Y > h*size.X {
+		w = max(1, (h*size.X+size.Y/2)/size.Y)
+	} else {
+		h = max(1, (w*size.Y+size.X/2)/size.X)
+	}
+	origin := bounds.Min.Add(image.Pt((bounds.Dx()-w)/2, (bounds.Dy()-h)/2))
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}
+}
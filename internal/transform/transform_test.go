@@ -0,0 +1,194 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves draws an image whose left half is red and right half blue
+func halves(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			if x < width/2 {
+				img.SetRGBA(x, y, red)
+			} else {
+				img.SetRGBA(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// at returns the color of img at x, y
+func at(img image.Image, x, y int) color.RGBA {
+	r, g, b, a := img.At(x, y).RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name                        string
+		resize, crop, fit, padColor string
+		want                        *Spec
+		wantErr                     bool
+	}{
+		{name: "nothing"},
+		{
+			name:   "resize",
+			resize: "800x600",
+			want:   &Spec{Resize: image.Pt(800, 600), PadColor: DefaultPadColor},
+		},
+		{
+			name: "crop",
+			crop: "100x50+10+20",
+			want: &Spec{Crop: image.Rect(10, 20, 110, 70), PadColor: DefaultPadColor},
+		},
+		{
+			name:     "contain with pad color",
+			resize:   "100x100",
+			fit:      FitContain,
+			padColor: "#0a0",
+			want:     &Spec{Resize: image.Pt(100, 100), Fit: FitContain, PadColor: color.RGBA{G: 0xaa, A: 255}},
+		},
+		{name: "invalid resize", resize: "800", wantErr: true},
+		{name: "zero resize", resize: "0x600", wantErr: true},
+		{
+			name:   "largest resize",
+			resize: "10000x10000",
+			want:   &Spec{Resize: image.Pt(MaxSize, MaxSize), PadColor: DefaultPadColor},
+		},
+		{name: "resize too wide", resize: "10001x600", wantErr: true},
+		{name: "resize too high", resize: "100000x100000", wantErr: true},
+		{name: "resize overflow", resize: "99999999999999999999x1", wantErr: true},
+		{name: "invalid crop", crop: "100x50", wantErr: true},
+		{name: "empty crop", crop: "0x50+0+0", wantErr: true},
+		{name: "unknown fit", resize: "100x100", fit: "fill", wantErr: true},
+		{name: "fit without resize", fit: FitCover, wantErr: true},
+		{name: "pad color without contain", resize: "100x100", padColor: "#000", wantErr: true},
+		{name: "invalid pad color", resize: "100x100", fit: FitContain, padColor: "black", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.resize, tt.crop, tt.fit, tt.padColor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Parse() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_Crop(t *testing.T) {
+	// GIVEN the blue right half of a 200x100 image
+	spec := &Spec{Crop: image.Rect(100, 0, 200, 100)}
+
+	// WHEN
+	got, err := Apply(halves(200, 100), spec)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if got.Bounds() != image.Rect(0, 0, 100, 100) {
+		t.Errorf("Apply() bounds = %v, want 100x100", got.Bounds())
+	}
+	if at(got, 0, 50) != blue || at(got, 99, 50) != blue {
+		t.Error("Expected only the blue half to be kept")
+	}
+}
+
+func TestApply_CropOutOfBounds(t *testing.T) {
+	_, err := Apply(halves(200, 100), &Spec{Crop: image.Rect(150, 0, 250, 100)})
+	if err == nil || err.Error() != "crop 100x100+150+0 exceeds the 200x100 image" {
+		t.Errorf("Expected an out of bounds error, got %v", err)
+	}
+}
+
+func TestApply_Resize(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+		// left and right are the expected colors at the middle of the left and right edges
+		left, right color.RGBA
+	}{
+		{"stretch", Spec{Resize: image.Pt(60, 60)}, red, blue},
+		{"cover keeps the center", Spec{Resize: image.Pt(60, 60), Fit: FitCover}, red, blue},
+		{"contain pads above and below", Spec{Resize: image.Pt(60, 60), Fit: FitContain, PadColor: DefaultPadColor}, red, blue},
+		{"cover of a narrow box keeps the center", Spec{Resize: image.Pt(10, 60), Fit: FitCover}, red, blue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			got, err := Apply(halves(200, 100), &tt.spec)
+
+			// THEN
+			if err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+			if got.Bounds().Size() != tt.spec.Resize {
+				t.Fatalf("Apply() size = %v, want %v", got.Bounds().Size(), tt.spec.Resize)
+			}
+			if at(got, 0, 30) != tt.left || at(got, got.Bounds().Dx()-1, 30) != tt.right {
+				t.Errorf("Apply() edges = %v %v, want %v %v", at(got, 0, 30), at(got, got.Bounds().Dx()-1, 30), tt.left, tt.right)
+			}
+		})
+	}
+}
+
+func TestApply_ContainPadding(t *testing.T) {
+	// GIVEN a 2:1 image in a square box
+	spec := &Spec{Resize: image.Pt(60, 60), Fit: FitContain, PadColor: color.RGBA{G: 255, A: 255}}
+
+	// WHEN
+	got, err := Apply(halves(200, 100), spec)
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if at(got, 30, 0) != spec.PadColor || at(got, 30, 59) != spec.PadColor {
+		t.Error("Expected the padding above and below the image")
+	}
+	if at(got, 5, 30) != red {
+		t.Error("Expected the image in the middle")
+	}
+}
+
+func TestApply_CoverCrops(t *testing.T) {
+	// GIVEN a square box, the 100x100 center of a 400x100 image is red on the left and blue on the right
+	img := halves(400, 100)
+	for y := range 100 {
+		for x := range 100 {
+			img.SetRGBA(x, y, color.RGBA{G: 255, A: 255})
+			img.SetRGBA(300+x, y, color.RGBA{G: 255, A: 255})
+		}
+	}
+
+	// WHEN
+	got, err := Apply(img, &Spec{Resize: image.Pt(50, 50), Fit: FitCover})
+
+	// THEN
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if at(got, 0, 25) != red || at(got, 49, 25) != blue {
+		t.Errorf("Expected the green sides to be cropped, got %v and %v", at(got, 0, 25), at(got, 49, 25))
+	}
+}
@@ -6,9 +6,72 @@ package urlbuilder
 import (
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strconv"
+	"strings"
+
+	"github.com/siakhooi/picsum/internal/nametemplate"
 )
 
+// DefaultBaseURL is the root of the public picsum.photos service
+const DefaultBaseURL = "https://picsum.photos"
+
+// Image formats picsum.photos serves by file extension
+const (
+	FormatJPG  = "jpg"
+	FormatWebP = "webp"
+)
+
+// contentTypes are the media types of the image formats
+var contentTypes = map[string]string{
+	FormatJPG:  "image/jpeg",
+	FormatWebP: "image/webp",
+}
+
+/*
+ParseBaseURL returns the root of every URL built for a Lorem Picsum mirror,
+without a trailing slash. The URL must use http or https, name a host and
+may carry a path prefix, but no query or fragment. An empty URL selects
+DefaultBaseURL.
+*/
+func ParseBaseURL(raw string) (string, error) {
+	if raw == "" {
+		return DefaultBaseURL, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %v", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid base URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q: missing host", raw)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("invalid base URL %q: credentials are not supported", raw)
+	}
+	if u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q: must not contain a query or fragment", raw)
+	}
+
+	return u.Scheme + "://" + u.Host + strings.TrimRight(u.EscapedPath(), "/"), nil
+}
+
+// ValidateFormat checks that format is empty or an image format picsum.photos serves
+func ValidateFormat(format string) error {
+	if _, ok := contentTypes[format]; format != "" && !ok {
+		return fmt.Errorf("unsupported format %q, must be %s or %s", format, FormatJPG, FormatWebP)
+	}
+	return nil
+}
+
+// ContentType returns the media type of the image format, empty if unknown
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
 // buildQueryParamsAndSuffix builds query parameters and filename suffix based on image options
 func buildQueryParamsAndSuffix(grayscale, blur bool, blurLevel int) (queryParams, filenameSuffix string) {
 	if grayscale && blurLevel > 0 {
@@ -30,45 +93,170 @@ func buildQueryParamsAndSuffix(grayscale, blur bool, blurLevel int) (queryParams
 	return queryParams, filenameSuffix
 }
 
-// BuildURL constructs the picsum.photos URL and filename based on arguments and options
-func BuildURL(args []string, imageID string, seed string, grayscale bool, blur bool, blurLevel int) (imageURL, filename string, err error) {
+// ParseSize parses the <size> or <width> <height> arguments, a single size is used for both dimensions
+func ParseSize(args []string) (width, height int, err error) {
+	switch len(args) {
+	case 1:
+		// Parse single number
+		width, err = strconv.Atoi(args[0])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid number: %s", args[0])
+		}
+		return width, width, nil
+	case 2:
+		// Parse two numbers
+		width, err = strconv.Atoi(args[0])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid first number: %s", args[0])
+		}
+		height, err = strconv.Atoi(args[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid second number: %s", args[1])
+		}
+		return width, height, nil
+	}
+	return 0, 0, fmt.Errorf("invalid arguments")
+}
+
+/*
+BuildURL constructs the picsum.photos URL under baseURL and the filename based on
+arguments and options. A format requests that variant by extension, otherwise the server default JPEG is
+requested and saved as .jpg.
+*/
+func BuildURL(baseURL string, args []string, imageID string, seed string, grayscale bool, blur bool, blurLevel int, format string) (imageURL, filename string, err error) {
 	subPath := ""
 	filePrefix := ""
 
 	if seed != "" {
 		subPath = fmt.Sprintf("seed/%s/", url.PathEscape(seed))
-		filePrefix = fmt.Sprintf("seed_%s_", seed)
+		filePrefix = fmt.Sprintf("seed_%s_", nametemplate.Sanitize(seed))
 	} else if imageID != "" {
 		subPath = fmt.Sprintf("id/%s/", url.PathEscape(imageID))
-		filePrefix = fmt.Sprintf("id_%s_", imageID)
+		filePrefix = fmt.Sprintf("id_%s_", nametemplate.Sanitize(imageID))
 	}
 
-	if len(args) == 1 {
-		// Parse single number
-		num1, err := strconv.Atoi(args[0])
-		if err != nil {
-			return "", "", fmt.Errorf("invalid number: %s", args[0])
-		}
-		imageURL = fmt.Sprintf("https://picsum.photos/%s%d", subPath, num1)
-		filename = fmt.Sprintf("%s%d", filePrefix, num1)
+	width, height, err := ParseSize(args)
+	if err != nil {
+		return "", "", err
+	}
 
+	if len(args) == 1 {
+		imageURL = fmt.Sprintf("%s/%s%d", baseURL, subPath, width)
+		filename = fmt.Sprintf("%s%d", filePrefix, width)
 	} else {
-		// Parse two numbers
-		num1, err := strconv.Atoi(args[0])
-		if err != nil {
-			return "", "", fmt.Errorf("invalid first number: %s", args[0])
-		}
-		num2, err := strconv.Atoi(args[1])
-		if err != nil {
-			return "", "", fmt.Errorf("invalid second number: %s", args[1])
-		}
-		imageURL = fmt.Sprintf("https://picsum.photos/%s%d/%d", subPath, num1, num2)
-		filename = fmt.Sprintf("%s%dx%d", filePrefix, num1, num2)
+		imageURL = fmt.Sprintf("%s/%s%d/%d", baseURL, subPath, width, height)
+		filename = fmt.Sprintf("%s%dx%d", filePrefix, width, height)
+	}
+
+	ext := FormatJPG
+	if format != "" {
+		imageURL += "." + format
+		ext = format
 	}
 
 	queryParams, filenameSuffix := buildQueryParamsAndSuffix(grayscale, blur, blurLevel)
 	imageURL += queryParams
-	filename += filenameSuffix + ".jpg"
+	filename += filenameSuffix + "." + ext
 
 	return imageURL, filename, nil
 }
+
+// NumberedURL makes the URL unique for the n-th image of a batch so that
+// picsum.photos (and any cache in between) serves a distinct random image
+func NumberedURL(imageURL string, n int) string {
+	separator := "?"
+	if strings.Contains(imageURL, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%srandom=%d", imageURL, separator, n)
+}
+
+// NumberedFilename inserts the n-th index of a batch of total images before the file extension
+func NumberedFilename(filename string, n, total int) string {
+	ext := filepath.Ext(filename)
+	width := len(strconv.Itoa(total))
+	return fmt.Sprintf("%s_%0*d%s", strings.TrimSuffix(filename, ext), width, n, ext)
+}
+
+// BuildListURL constructs the URL of a page of the picsum.photos catalog under baseURL
+func BuildListURL(baseURL string, page, limit int) string {
+	return fmt.Sprintf("%s/v2/list?page=%d&limit=%d", baseURL, page, limit)
+}
+
+// BuildInfoURL constructs the metadata URL under baseURL of the image selected by imageID or seed
+func BuildInfoURL(baseURL, imageID, seed string) (string, error) {
+	if imageID != "" && seed != "" {
+		return "", fmt.Errorf("options --id and --seed are mutually exclusive")
+	}
+	if seed != "" {
+		return fmt.Sprintf("%s/seed/%s/info", baseURL, url.PathEscape(seed)), nil
+	}
+	if imageID != "" {
+		return fmt.Sprintf("%s/id/%s/info", baseURL, url.PathEscape(imageID)), nil
+	}
+	return "", fmt.Errorf("one of --id or --seed is required")
+}
+
+// Route is an image request expressed in the picsum.photos URL scheme
+type Route struct {
+	// Args are the <size> or <width> <height> arguments
+	Args      []string
+	ImageID   string
+	Seed      string
+	Grayscale bool
+	Blur      bool
+	BlurLevel int
+	// Format is the requested image format, the server default when empty
+	Format string
+}
+
+/*
+ParsePath is the inverse of BuildURL: it parses the path and query of an
+image URL such as /200/300, /id/237/200 or /seed/picsum/200/300?grayscale&blur=2.
+The last path segment may carry a .jpg or .webp extension.
+*/
+func ParsePath(path string, query url.Values) (*Route, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	route := &Route{}
+
+	switch segments[0] {
+	case "id", "seed":
+		if len(segments) < 2 || segments[1] == "" {
+			return nil, fmt.Errorf("missing %s in path: %s", segments[0], path)
+		}
+		if segments[0] == "id" {
+			route.ImageID = segments[1]
+		} else {
+			route.Seed = segments[1]
+		}
+		segments = segments[2:]
+	}
+
+	if len(segments) > 0 {
+		last := len(segments) - 1
+		for format := range contentTypes {
+			if trimmed, ok := strings.CutSuffix(segments[last], "."+format); ok {
+				segments[last] = trimmed
+				route.Format = format
+			}
+		}
+	}
+	width, height, err := ParseSize(segments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("size must be positive: %s", path)
+	}
+	route.Args = segments
+
+	route.Grayscale = query.Has("grayscale")
+	if query.Has("blur") {
+		if value := query.Get("blur"); value == "" {
+			route.Blur = true
+		} else if route.BlurLevel, err = strconv.Atoi(value); err != nil || route.BlurLevel < 1 || route.BlurLevel > 10 {
+			return nil, fmt.Errorf("blur level must be between 1 and 10, got %s", value)
+		}
+	}
+	return route, nil
+}